@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/primevprotocol/mev-oracle/pkg/keysigner"
+	"github.com/primevprotocol/mev-oracle/pkg/node"
+	"github.com/urfave/cli/v2"
+	"github.com/urfave/cli/v2/altsrc"
+)
+
+var (
+	optionBackfillFrom = &cli.Int64Flag{
+		Name:     "from",
+		Usage:    "first L1 block to backfill",
+		Required: true,
+	}
+
+	optionBackfillTo = &cli.Int64Flag{
+		Name:     "to",
+		Usage:    "last L1 block to backfill",
+		Required: true,
+	}
+
+	optionBackfillNoPost = &cli.BoolFlag{
+		Name:  "no-post",
+		Usage: "derive the settlements without posting them to the settlement chain, recording them as shadow settlements",
+	}
+)
+
+// backfillCommand returns the command processing a range of past L1 blocks
+// with the node configuration given by flags.
+func backfillCommand(flags []cli.Flag) *cli.Command {
+	backfillFlags := append([]cli.Flag{
+		optionBackfillFrom,
+		optionBackfillTo,
+		optionBackfillNoPost,
+	}, flags...)
+
+	return &cli.Command{
+		Name:   "backfill",
+		Usage:  "Register the winners and derive the settlements of a range of past L1 blocks",
+		Flags:  backfillFlags,
+		Before: altsrc.InitInputSourceWithContext(flags, altsrc.NewYamlSourceFromFlagFunc(optionConfig.Name)),
+		Action: func(c *cli.Context) error {
+			logger, err := newLogger(
+				c.String(optionLogLevel.Name),
+				c.String(optionLogFmt.Name),
+				c.String(optionLogTags.Name),
+				c.App.Writer,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to create logger: %w", err)
+			}
+
+			post := !c.Bool(optionBackfillNoPost.Name)
+
+			// The key is only needed to sign the settlement transactions.
+			var keySigner keysigner.KeySigner
+			if post {
+				if err := verifyKeystorePasswordPresence(c); err != nil {
+					return err
+				}
+				keySigner, err = setupKeySigner(c)
+				if err != nil {
+					return fmt.Errorf("failed to setup key signer: %w", err)
+				}
+			}
+
+			ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			from, to := c.Int64(optionBackfillFrom.Name), c.Int64(optionBackfillTo.Name)
+			err = node.Backfill(ctx, nodeOptions(c, logger, keySigner), from, to, post)
+			if err != nil {
+				return fmt.Errorf("failed to backfill blocks %d to %d: %w", from, to, err)
+			}
+
+			logger.Info("backfill complete", "from", from, "to", to, "posted", post)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/primevprotocol/mev-oracle/pkg/node"
+	"github.com/primevprotocol/mev-oracle/pkg/store"
+	"github.com/urfave/cli/v2"
+	"github.com/urfave/cli/v2/altsrc"
+)
+
+var (
+	optionDisputesLimit = &cli.IntFlag{
+		Name:  "limit",
+		Usage: "maximum number of disputed blocks to list",
+		Value: 100,
+	}
+
+	optionDisputeBlock = &cli.Int64Flag{
+		Name:     "block",
+		Usage:    "number of the disputed block",
+		Required: true,
+	}
+
+	optionDisputeWinner = &cli.StringFlag{
+		Name:     "winner",
+		Usage:    "name of the builder which won the disputed block, among its candidates",
+		Required: true,
+	}
+)
+
+// disputesCommand returns the command used by operators to inspect and resolve
+// the blocks for which the winner sources disagreed.
+func disputesCommand() *cli.Command {
+	dbFlags := []cli.Flag{
+		optionConfig,
+		optionPgHost,
+		optionPgPort,
+		optionPgUser,
+		optionPgPassword,
+		optionPgDbname,
+	}
+	before := altsrc.InitInputSourceWithContext(dbFlags, altsrc.NewYamlSourceFromFlagFunc(optionConfig.Name))
+
+	return &cli.Command{
+		Name:  "disputes",
+		Usage: "Manage the blocks with disputed winners",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "list",
+				Usage:  "List the disputed blocks",
+				Flags:  append([]cli.Flag{optionDisputesLimit}, dbFlags...),
+				Before: before,
+				Action: func(c *cli.Context) error {
+					return withStore(c, func(st *store.Store) error {
+						blocks, err := st.DisputedBlocks(c.Int(optionDisputesLimit.Name), 0)
+						if err != nil {
+							return fmt.Errorf("failed to get disputed blocks: %w", err)
+						}
+
+						w := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+						fmt.Fprintln(w, "BLOCK\tLEADING\tCANDIDATES")
+						for _, b := range blocks {
+							fmt.Fprintf(w, "%d\t%s\t%s\n", b.BlockNumber, b.Builder, formatCandidates(b.Candidates))
+						}
+						return w.Flush()
+					})
+				},
+			},
+			{
+				Name:   "resolve",
+				Usage:  "Resolve a disputed block by setting its winner, picked up by a running node at its next poll of the winners",
+				Flags:  append([]cli.Flag{optionDisputeBlock, optionDisputeWinner}, dbFlags...),
+				Before: before,
+				Action: func(c *cli.Context) error {
+					return withStore(c, func(st *store.Store) error {
+						blockNum := c.Int64(optionDisputeBlock.Name)
+						winner := c.String(optionDisputeWinner.Name)
+						if err := st.ResolveDispute(c.Context, blockNum, winner); err != nil {
+							return fmt.Errorf("failed to resolve block %d: %w", blockNum, err)
+						}
+						fmt.Fprintf(c.App.Writer, "block %d resolved with winner %s\n", blockNum, winner)
+						return nil
+					})
+				},
+			},
+		},
+	}
+}
+
+// withStore opens the store configured by the database flags and calls fn
+// with it.
+func withStore(c *cli.Context, fn func(*store.Store) error) error {
+	st, closer, err := node.OpenStore(&node.Options{
+		PgHost:     c.String(optionPgHost.Name),
+		PgPort:     c.Int(optionPgPort.Name),
+		PgUser:     c.String(optionPgUser.Name),
+		PgPassword: c.String(optionPgPassword.Name),
+		PgDbname:   c.String(optionPgDbname.Name),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to open store: %w", err)
+	}
+	defer closer.Close()
+
+	return fn(st)
+}
+
+func formatCandidates(candidates map[string]string) string {
+	sources := make([]string, 0, len(candidates))
+	for source := range candidates {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	pairs := make([]string, 0, len(sources))
+	for _, source := range sources {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", source, candidates[source]))
+	}
+	return strings.Join(pairs, ", ")
+}
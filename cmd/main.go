@@ -12,8 +12,11 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	contracts "github.com/primevprotocol/contracts-abi/config"
+	"github.com/primevprotocol/mev-oracle/pkg/indexer"
 	"github.com/primevprotocol/mev-oracle/pkg/keysigner"
+	"github.com/primevprotocol/mev-oracle/pkg/l1Listener"
 	"github.com/primevprotocol/mev-oracle/pkg/node"
+	"github.com/primevprotocol/mev-oracle/pkg/updater"
 	"github.com/urfave/cli/v2"
 	"github.com/urfave/cli/v2/altsrc"
 )
@@ -43,6 +46,8 @@ var (
 	}
 )
 
+var winnerResolvers = []string{"extra-data", "fee-recipient", "relay"}
+
 var (
 	optionConfig = &cli.StringFlag{
 		Name:    "config",
@@ -101,6 +106,34 @@ var (
 		EnvVars: []string{"MEV_ORACLE_L1_RPC_URL"},
 	})
 
+	optionL1RPCUrls = altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+		Name:    "l1-rpc-urls",
+		Usage:   "URLs of additional L1 RPC endpoints used for failover and quorum",
+		EnvVars: []string{"MEV_ORACLE_L1_RPC_URLS"},
+	})
+
+	optionL1Quorum = altsrc.NewIntFlag(&cli.IntFlag{
+		Name:    "l1-quorum",
+		Usage:   "number of L1 RPC endpoints which need to agree on the blocks, a majority of them, 1 disables the quorum and fails over between the endpoints",
+		EnvVars: []string{"MEV_ORACLE_L1_QUORUM"},
+		Value:   1,
+		Action: func(c *cli.Context, q int) error {
+			if endpoints := 1 + len(c.StringSlice(optionL1RPCUrls.Name)); q < 1 || q > endpoints {
+				return fmt.Errorf("invalid l1-quorum %d, expected 1 <= quorum <= %d", q, endpoints)
+			}
+			if endpoints := 1 + len(c.StringSlice(optionL1RPCUrls.Name)); q > 1 && q*2 <= endpoints {
+				return fmt.Errorf("invalid l1-quorum %d, expected a majority of the %d endpoints", q, endpoints)
+			}
+			return nil
+		},
+	})
+
+	optionBeaconUrl = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "beacon-url",
+		Usage:   "URL of the beacon node API used to map the L1 blocks to slots and track missed slots",
+		EnvVars: []string{"MEV_ORACLE_BEACON_URL"},
+	})
+
 	optionSettlementRPCUrl = altsrc.NewStringFlag(&cli.StringFlag{
 		Name:    "settlement-rpc-url",
 		Usage:   "URL for settlement RPC",
@@ -164,12 +197,241 @@ var (
 		Value:   0,
 	})
 
+	optionL1Finality = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "l1-finality",
+		Usage:   "L1 block tag to follow, options are 'latest', 'safe' or 'finalized'",
+		EnvVars: []string{"MEV_ORACLE_L1_FINALITY"},
+		Value:   "latest",
+		Action:  stringInCheck("l1-finality", []string{"latest", "safe", "finalized"}),
+	})
+
+	optionWinnerResolver = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "winner-resolver",
+		Usage:   "strategy used to determine the winner of an L1 block, options are 'extra-data', 'fee-recipient' or 'relay'",
+		EnvVars: []string{"MEV_ORACLE_WINNER_RESOLVER"},
+		Value:   "extra-data",
+		Action:  stringInCheck("winner-resolver", winnerResolvers),
+	})
+
+	optionWinnerSources = altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+		Name:    "winner-sources",
+		Usage:   "strategies reconciled to determine the winner of an L1 block, overrides winner-resolver if set",
+		EnvVars: []string{"MEV_ORACLE_WINNER_SOURCES"},
+		Action: func(ctx *cli.Context, s []string) error {
+			for _, source := range s {
+				if err := stringInCheck("winner-sources", winnerResolvers)(ctx, source); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+
+	optionWinnerConsensus = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "winner-consensus",
+		Usage:   "policy used to reconcile the winner sources, options are 'any', 'majority' or 'all'",
+		EnvVars: []string{"MEV_ORACLE_WINNER_CONSENSUS"},
+		Value:   "all",
+		Action:  stringInCheck("winner-consensus", []string{"any", "majority", "all"}),
+	})
+
+	optionBuilderRegistry = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "builder-registry",
+		Usage:   "path to a YAML file with the aliases and patterns used to canonicalize the extra data into builder names, and the fee recipients of the builders",
+		EnvVars: []string{"MEV_ORACLE_BUILDER_REGISTRY"},
+	})
+
+	optionRelayUrls = altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+		Name:    "relay-urls",
+		Usage:   "URLs of the MEV-Boost relays queried by the relay winner resolver, required if relay is the winner resolver or one of the winner sources",
+		EnvVars: []string{"MEV_ORACLE_RELAY_URLS"},
+	})
+
+	optionRelayBuilderPubkeys = altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+		Name:    "relay-builder-pubkeys",
+		Usage:   "list of <name:pubkey> pairs mapping builder public keys reported by the relays to builder names",
+		EnvVars: []string{"MEV_ORACLE_RELAY_BUILDER_PUBKEYS"},
+		Action: func(ctx *cli.Context, s []string) error {
+			for i, p := range s {
+				if len(strings.Split(p, ":")) != 2 {
+					return fmt.Errorf("invalid relay-builder-pubkeys at index %d, expecting <name:pubkey>", i)
+				}
+			}
+			return nil
+		},
+	})
+
+	optionRelayPendingTimeout = altsrc.NewDurationFlag(&cli.DurationFlag{
+		Name:    "relay-pending-timeout",
+		Usage:   "age of a block after which it is registered without a winner if none of the relays reported its delivery, the later blocks are not registered meanwhile so a longer timeout delays all the winners behind an undelivered block",
+		EnvVars: []string{"MEV_ORACLE_RELAY_PENDING_TIMEOUT"},
+		Value:   l1Listener.DefaultRelayPendingTimeout,
+	})
+
 	optionOverrideWinners = altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
 		Name:    "override-winners",
 		Usage:   "Override winners for testing",
 		EnvVars: []string{"MEV_ORACLE_OVERRIDE_WINNERS"},
 	})
 
+	optionUpdaterParallelism = altsrc.NewIntFlag(&cli.IntFlag{
+		Name:    "updater-parallelism",
+		Usage:   "number of commitments fetched concurrently while processing the winner blocks",
+		EnvVars: []string{"MEV_ORACLE_UPDATER_PARALLELISM"},
+		Value:   8,
+		Action: func(c *cli.Context, p int) error {
+			if p < 1 {
+				return fmt.Errorf("invalid updater-parallelism %d, expected at least 1", p)
+			}
+			return nil
+		},
+	})
+
+	optionPreconfBatchSize = altsrc.NewIntFlag(&cli.IntFlag{
+		Name:    "preconf-batch-size",
+		Usage:   "maximum number of commitments fetched in a single JSON-RPC batch request",
+		EnvVars: []string{"MEV_ORACLE_PRECONF_BATCH_SIZE"},
+		Value:   updater.DefaultBatchSize,
+		Action: func(c *cli.Context, size int) error {
+			if size < 1 {
+				return fmt.Errorf("invalid preconf-batch-size %d, expected at least 1", size)
+			}
+			return nil
+		},
+	})
+
+	optionBuilderCacheTTL = altsrc.NewDurationFlag(&cli.DurationFlag{
+		Name:    "builder-cache-ttl",
+		Usage:   "duration for which a builder address is cached before it is fetched again from the oracle contract",
+		EnvVars: []string{"MEV_ORACLE_BUILDER_CACHE_TTL"},
+		Value:   updater.DefaultBuilderCacheTTL,
+	})
+
+	optionReceiptPolicy = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "receipt-policy",
+		Usage:   "settlement of the winner's commitments with reverted transactions, options are 'ignore', 'reward', 'slash' or 'flag'",
+		EnvVars: []string{"MEV_ORACLE_RECEIPT_POLICY"},
+		Value:   string(updater.ReceiptPolicyIgnore),
+		Action:  stringInCheck("receipt-policy", updater.ReceiptPolicies),
+	})
+
+	optionBundlePolicy = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "bundle-policy",
+		Usage:   "inclusion required to reward the winner's commitments, options are 'contiguous', 'ordered', 'any-order' or 'top-of-block'",
+		EnvVars: []string{"MEV_ORACLE_BUNDLE_POLICY"},
+		Value:   updater.BundlePolicyContiguous,
+		Action:  stringInCheck("bundle-policy", updater.BundlePolicies),
+	})
+
+	optionSingleTxPolicy = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "single-tx-policy",
+		Usage:   "inclusion required to reward the winner's commitments to a single transaction, defaults to the bundle policy",
+		EnvVars: []string{"MEV_ORACLE_SINGLE_TX_POLICY"},
+		Action:  stringInCheck("single-tx-policy", updater.BundlePolicies),
+	})
+
+	optionDecayCurve = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "decay-curve",
+		Usage:   "decay of the bids implemented by the oracle contract, options are 'linear' or 'none'",
+		EnvVars: []string{"MEV_ORACLE_DECAY_CURVE"},
+		Value:   string(updater.DefaultDecayConfig.Curve),
+		Action:  stringInCheck("decay-curve", updater.DecayCurves),
+	})
+
+	optionDecayTimestampUnit = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "decay-timestamp-unit",
+		Usage:   "unit of the decay timestamps of the commitments, options are 's' or 'ms'",
+		EnvVars: []string{"MEV_ORACLE_DECAY_TIMESTAMP_UNIT"},
+		Value:   string(updater.DefaultDecayConfig.CommitmentUnit),
+		Action:  stringInCheck("decay-timestamp-unit", updater.TimestampUnits),
+	})
+
+	optionBlockTimestampUnit = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "block-timestamp-unit",
+		Usage:   "unit of the settlement chain block timestamps, options are 's' or 'ms'",
+		EnvVars: []string{"MEV_ORACLE_BLOCK_TIMESTAMP_UNIT"},
+		Value:   string(updater.DefaultDecayConfig.BlockTimeUnit),
+		Action:  stringInCheck("block-timestamp-unit", updater.TimestampUnits),
+	})
+
+	optionDecayPrecision = altsrc.NewInt64Flag(&cli.Int64Flag{
+		Name:    "decay-precision",
+		Usage:   "value of a full decay expected by the oracle contract, 100 for percent or 10000 for basis points",
+		EnvVars: []string{"MEV_ORACLE_DECAY_PRECISION"},
+		Value:   updater.DefaultDecayConfig.Precision,
+		Action: func(c *cli.Context, p int64) error {
+			if p < 1 || p > updater.BasisPoints {
+				return fmt.Errorf("invalid decay-precision %d, expected 1 to %d", p, updater.BasisPoints)
+			}
+			return nil
+		},
+	})
+
+	optionBlockMaxAttempts = altsrc.NewIntFlag(&cli.IntFlag{
+		Name:    "block-max-attempts",
+		Usage:   "number of failed attempts to process a block after which it is quarantined, 0 to retry forever",
+		EnvVars: []string{"MEV_ORACLE_BLOCK_MAX_ATTEMPTS"},
+		Value:   updater.DefaultRetryPolicy.MaxAttempts,
+		Action: func(c *cli.Context, attempts int) error {
+			if attempts < 0 {
+				return fmt.Errorf("invalid block-max-attempts %d, expected at least 0", attempts)
+			}
+			return nil
+		},
+	})
+
+	optionBlockRetryBackoff = altsrc.NewDurationFlag(&cli.DurationFlag{
+		Name:    "block-retry-backoff",
+		Usage:   "time to wait before retrying a failed block, doubled on every further failure",
+		EnvVars: []string{"MEV_ORACLE_BLOCK_RETRY_BACKOFF"},
+		Value:   updater.DefaultRetryPolicy.Backoff,
+	})
+
+	optionBlockRetryMaxBackoff = altsrc.NewDurationFlag(&cli.DurationFlag{
+		Name:    "block-retry-max-backoff",
+		Usage:   "maximum time to wait before retrying a failed block",
+		EnvVars: []string{"MEV_ORACLE_BLOCK_RETRY_MAX_BACKOFF"},
+		Value:   updater.DefaultRetryPolicy.MaxBackoff,
+	})
+
+	optionBuilderTimeout = altsrc.NewDurationFlag(&cli.DurationFlag{
+		Name:    "builder-unregistered-timeout",
+		Usage:   "time for which the blocks won by a builder not registered in the oracle contract are parked before their commitments are returned, 0 to return them right away as before parking was introduced",
+		EnvVars: []string{"MEV_ORACLE_BUILDER_UNREGISTERED_TIMEOUT"},
+		Value:   updater.DefaultBuilderTimeout,
+		Action: func(c *cli.Context, timeout time.Duration) error {
+			if timeout < 0 {
+				return fmt.Errorf("invalid builder-unregistered-timeout %s, expected at least 0", timeout)
+			}
+			return nil
+		},
+	})
+
+	optionCommitmentIndexer = altsrc.NewBoolFlag(&cli.BoolFlag{
+		Name:    "commitment-indexer",
+		Usage:   "index the commitments from the settlement chain logs and read the commitments of the blocks from the index",
+		EnvVars: []string{"MEV_ORACLE_COMMITMENT_INDEXER"},
+	})
+
+	optionIndexerStartBlock = altsrc.NewUint64Flag(&cli.Uint64Flag{
+		Name:    "indexer-start-block",
+		Usage:   "settlement chain block from which the commitments are first indexed, at the latest the deployment block of the preconf contract",
+		EnvVars: []string{"MEV_ORACLE_INDEXER_START_BLOCK"},
+	})
+
+	optionIndexerCheckInterval = altsrc.NewIntFlag(&cli.IntFlag{
+		Name:    "indexer-check-interval",
+		Usage:   "number of blocks read from the commitment index between two checks against the contract, 0 to disable",
+		EnvVars: []string{"MEV_ORACLE_INDEXER_CHECK_INTERVAL"},
+		Value:   indexer.DefaultCheckInterval,
+		Action: func(c *cli.Context, interval int) error {
+			if interval < 0 {
+				return fmt.Errorf("invalid indexer-check-interval %d, expected at least 0", interval)
+			}
+			return nil
+		},
+	})
+
 	optionKeystorePassword = altsrc.NewStringFlag(&cli.StringFlag{
 		Name:    "keystore-password",
 		Usage:   "use to access keystore",
@@ -193,6 +455,9 @@ func main() {
 		optionLogLevel,
 		optionLogTags,
 		optionL1RPCUrl,
+		optionL1RPCUrls,
+		optionL1Quorum,
+		optionBeaconUrl,
 		optionSettlementRPCUrl,
 		optionOracleContractAddr,
 		optionPreconfContractAddr,
@@ -202,10 +467,37 @@ func main() {
 		optionPgPassword,
 		optionPgDbname,
 		optionLaggerdMode,
+		optionL1Finality,
+		optionWinnerResolver,
+		optionWinnerSources,
+		optionWinnerConsensus,
+		optionBuilderRegistry,
+		optionRelayUrls,
+		optionRelayBuilderPubkeys,
+		optionRelayPendingTimeout,
 		optionOverrideWinners,
+		optionUpdaterParallelism,
+		optionPreconfBatchSize,
+		optionBuilderCacheTTL,
+		optionReceiptPolicy,
+		optionBundlePolicy,
+		optionSingleTxPolicy,
+		optionDecayCurve,
+		optionDecayTimestampUnit,
+		optionBlockTimestampUnit,
+		optionDecayPrecision,
+		optionBlockMaxAttempts,
+		optionBlockRetryBackoff,
+		optionBlockRetryMaxBackoff,
+		optionBuilderTimeout,
+		optionCommitmentIndexer,
+		optionIndexerStartBlock,
+		optionIndexerCheckInterval,
 		optionKeystorePath,
 		optionKeystorePassword,
 	}
+	startFlags := append([]cli.Flag{optionShadow}, flags...)
+
 	app := &cli.App{
 		Name:  "mev-oracle",
 		Usage: "Entry point for mev-oracle",
@@ -213,12 +505,16 @@ func main() {
 			{
 				Name:   "start",
 				Usage:  "Start the mev-oracle node",
-				Flags:  flags,
-				Before: altsrc.InitInputSourceWithContext(flags, altsrc.NewYamlSourceFromFlagFunc(optionConfig.Name)),
+				Flags:  startFlags,
+				Before: altsrc.InitInputSourceWithContext(startFlags, altsrc.NewYamlSourceFromFlagFunc(optionConfig.Name)),
 				Action: func(c *cli.Context) error {
 					return initializeApplication(c)
 				},
 			},
+			backfillCommand(flags),
+			disputesCommand(),
+			quarantineCommand(),
+			shadowDiffCommand(),
 		}}
 
 	if err := app.Run(os.Args); err != nil {
@@ -257,28 +553,20 @@ func launchOracleWithConfig(c *cli.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to create logger: %w", err)
 	}
-	keySigner, err := setupKeySigner(c)
-	if err != nil {
-		return fmt.Errorf("failed to setup key signer: %w", err)
+
+	// The key is only needed to sign the settlement transactions.
+	var keySigner keysigner.KeySigner
+	if c.Bool(optionShadow.Name) {
+		logger.Info("running in shadow mode, settlements are recorded without posting")
+	} else {
+		keySigner, err = setupKeySigner(c)
+		if err != nil {
+			return fmt.Errorf("failed to setup key signer: %w", err)
+		}
+		logger.Info("key signer account", "address", keySigner.GetAddress().Hex(), "url", keySigner.String())
 	}
-	logger.Info("key signer account", "address", keySigner.GetAddress().Hex(), "url", keySigner.String())
-
-	nd, err := node.NewNode(&node.Options{
-		Logger:              logger,
-		KeySigner:           keySigner,
-		HTTPPort:            c.Int(optionHTTPPort.Name),
-		L1RPCUrl:            c.String(optionL1RPCUrl.Name),
-		SettlementRPCUrl:    c.String(optionSettlementRPCUrl.Name),
-		OracleContractAddr:  common.HexToAddress(c.String(optionOracleContractAddr.Name)),
-		PreconfContractAddr: common.HexToAddress(c.String(optionPreconfContractAddr.Name)),
-		PgHost:              c.String(optionPgHost.Name),
-		PgPort:              c.Int(optionPgPort.Name),
-		PgUser:              c.String(optionPgUser.Name),
-		PgPassword:          c.String(optionPgPassword.Name),
-		PgDbname:            c.String(optionPgDbname.Name),
-		LaggerdMode:         c.Int(optionLaggerdMode.Name),
-		OverrideWinners:     c.StringSlice(optionOverrideWinners.Name),
-	})
+
+	nd, err := node.NewNode(nodeOptions(c, logger, keySigner))
 	if err != nil {
 		return fmt.Errorf("failed starting node: %w", err)
 	}
@@ -305,6 +593,55 @@ func launchOracleWithConfig(c *cli.Context) error {
 	return nil
 }
 
+// nodeOptions returns the node options configured by the CLI context.
+func nodeOptions(c *cli.Context, logger *slog.Logger, keySigner keysigner.KeySigner) *node.Options {
+	return &node.Options{
+		Logger:               logger,
+		KeySigner:            keySigner,
+		HTTPPort:             c.Int(optionHTTPPort.Name),
+		L1RPCUrl:             c.String(optionL1RPCUrl.Name),
+		L1RPCUrls:            c.StringSlice(optionL1RPCUrls.Name),
+		L1Quorum:             c.Int(optionL1Quorum.Name),
+		BeaconUrl:            c.String(optionBeaconUrl.Name),
+		SettlementRPCUrl:     c.String(optionSettlementRPCUrl.Name),
+		OracleContractAddr:   common.HexToAddress(c.String(optionOracleContractAddr.Name)),
+		PreconfContractAddr:  common.HexToAddress(c.String(optionPreconfContractAddr.Name)),
+		PgHost:               c.String(optionPgHost.Name),
+		PgPort:               c.Int(optionPgPort.Name),
+		PgUser:               c.String(optionPgUser.Name),
+		PgPassword:           c.String(optionPgPassword.Name),
+		PgDbname:             c.String(optionPgDbname.Name),
+		LaggerdMode:          c.Int(optionLaggerdMode.Name),
+		L1Finality:           c.String(optionL1Finality.Name),
+		WinnerResolver:       c.String(optionWinnerResolver.Name),
+		WinnerSources:        c.StringSlice(optionWinnerSources.Name),
+		WinnerConsensus:      c.String(optionWinnerConsensus.Name),
+		BuilderRegistry:      c.String(optionBuilderRegistry.Name),
+		RelayUrls:            c.StringSlice(optionRelayUrls.Name),
+		RelayBuilderPubkeys:  relayBuilderPubkeys(c.StringSlice(optionRelayBuilderPubkeys.Name)),
+		RelayPendingTimeout:  c.Duration(optionRelayPendingTimeout.Name),
+		OverrideWinners:      c.StringSlice(optionOverrideWinners.Name),
+		UpdaterParallelism:   c.Int(optionUpdaterParallelism.Name),
+		PreconfBatchSize:     c.Int(optionPreconfBatchSize.Name),
+		BuilderCacheTTL:      c.Duration(optionBuilderCacheTTL.Name),
+		ReceiptPolicy:        c.String(optionReceiptPolicy.Name),
+		BundlePolicy:         c.String(optionBundlePolicy.Name),
+		SingleTxPolicy:       c.String(optionSingleTxPolicy.Name),
+		DecayCurve:           c.String(optionDecayCurve.Name),
+		DecayTimestampUnit:   c.String(optionDecayTimestampUnit.Name),
+		BlockTimestampUnit:   c.String(optionBlockTimestampUnit.Name),
+		DecayPrecision:       c.Int64(optionDecayPrecision.Name),
+		BlockMaxAttempts:     c.Int(optionBlockMaxAttempts.Name),
+		BlockRetryBackoff:    c.Duration(optionBlockRetryBackoff.Name),
+		BlockRetryMaxBackoff: c.Duration(optionBlockRetryMaxBackoff.Name),
+		BuilderTimeout:       c.Duration(optionBuilderTimeout.Name),
+		CommitmentIndexer:    c.Bool(optionCommitmentIndexer.Name),
+		IndexerStartBlock:    c.Uint64(optionIndexerStartBlock.Name),
+		IndexerCheckInterval: c.Int(optionIndexerCheckInterval.Name),
+		Shadow:               c.Bool(optionShadow.Name),
+	}
+}
+
 // newLogger initializes a *slog.Logger with specified level, format, and sink.
 //   - lvl: string representation of slog.Level
 //   - logFmt: format of the log output: "text", "json", "none" defaults to "json"
@@ -352,6 +689,20 @@ func newLogger(lvl, logFmt, tags string, sink io.Writer) (*slog.Logger, error) {
 	return logger.With(args...), nil
 }
 
+// relayBuilderPubkeys maps the builder public keys to the builder names given
+// as <name:pubkey> pairs.
+func relayBuilderPubkeys(pairs []string) map[string]string {
+	pubkeys := make(map[string]string, len(pairs))
+	for _, p := range pairs {
+		kv := strings.Split(p, ":")
+		if len(kv) != 2 {
+			continue
+		}
+		pubkeys[kv[1]] = kv[0]
+	}
+	return pubkeys
+}
+
 func setupKeySigner(c *cli.Context) (keysigner.KeySigner, error) {
 	if c.IsSet(optionKeystorePath.Name) {
 		return keysigner.NewKeystoreSigner(c.String(optionKeystorePath.Name), c.String(optionKeystorePassword.Name))
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/primevprotocol/mev-oracle/pkg/store"
+	"github.com/urfave/cli/v2"
+	"github.com/urfave/cli/v2/altsrc"
+)
+
+var (
+	optionQuarantineLimit = &cli.IntFlag{
+		Name:  "limit",
+		Usage: "maximum number of quarantined blocks to list",
+		Value: 100,
+	}
+
+	optionQuarantineBlock = &cli.Int64Flag{
+		Name:     "block",
+		Usage:    "number of the quarantined block",
+		Required: true,
+	}
+)
+
+// quarantineCommand returns the command used by operators to inspect and
+// retry the blocks which failed to be processed too many times.
+func quarantineCommand() *cli.Command {
+	dbFlags := []cli.Flag{
+		optionConfig,
+		optionPgHost,
+		optionPgPort,
+		optionPgUser,
+		optionPgPassword,
+		optionPgDbname,
+	}
+	before := altsrc.InitInputSourceWithContext(dbFlags, altsrc.NewYamlSourceFromFlagFunc(optionConfig.Name))
+
+	return &cli.Command{
+		Name:  "quarantine",
+		Usage: "Manage the blocks quarantined after repeated processing failures",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "list",
+				Usage:  "List the quarantined blocks",
+				Flags:  append([]cli.Flag{optionQuarantineLimit}, dbFlags...),
+				Before: before,
+				Action: func(c *cli.Context) error {
+					return withStore(c, func(st *store.Store) error {
+						blocks, err := st.QuarantinedBlocks(c.Int(optionQuarantineLimit.Name), 0)
+						if err != nil {
+							return fmt.Errorf("failed to get quarantined blocks: %w", err)
+						}
+
+						w := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+						fmt.Fprintln(w, "BLOCK\tBUILDER\tATTEMPTS\tQUARANTINED\tERROR")
+						for _, b := range blocks {
+							fmt.Fprintf(
+								w,
+								"%d\t%s\t%d\t%s\t%s\n",
+								b.BlockNumber,
+								b.Builder,
+								b.Attempts,
+								b.QuarantinedAt.UTC().Format(time.RFC3339),
+								strings.ReplaceAll(b.LastError, "\n", " "),
+							)
+						}
+						return w.Flush()
+					})
+				},
+			},
+			{
+				Name:   "retry",
+				Usage:  "Release a quarantined block so that it is processed again",
+				Flags:  append([]cli.Flag{optionQuarantineBlock}, dbFlags...),
+				Before: before,
+				Action: func(c *cli.Context) error {
+					return withStore(c, func(st *store.Store) error {
+						blockNum := c.Int64(optionQuarantineBlock.Name)
+						if err := st.RetryQuarantined(c.Context, blockNum); err != nil {
+							return fmt.Errorf("failed to retry block %d: %w", blockNum, err)
+						}
+						fmt.Fprintf(c.App.Writer, "block %d released for processing\n", blockNum)
+						return nil
+					})
+				},
+			},
+		},
+	}
+}
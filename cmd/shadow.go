@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/primevprotocol/mev-oracle/pkg/node"
+	"github.com/primevprotocol/mev-oracle/pkg/settler"
+	"github.com/urfave/cli/v2"
+	"github.com/urfave/cli/v2/altsrc"
+)
+
+var (
+	optionShadow = altsrc.NewBoolFlag(&cli.BoolFlag{
+		Name:    "shadow",
+		Usage:   "record the settlements instead of posting them, to run next to another oracle",
+		EnvVars: []string{"MEV_ORACLE_SHADOW"},
+	})
+
+	optionShadowDiffFrom = &cli.Int64Flag{
+		Name:     "from",
+		Usage:    "first L1 block to compare",
+		Required: true,
+	}
+
+	optionShadowDiffTo = &cli.Int64Flag{
+		Name:     "to",
+		Usage:    "last L1 block to compare",
+		Required: true,
+	}
+
+	optionShadowDiffReferenceDB = &cli.StringFlag{
+		Name:  "reference-db",
+		Usage: "connection string of the database of the oracle to compare with",
+	}
+
+	optionShadowDiffChainFrom = &cli.Uint64Flag{
+		Name:  "chain-from",
+		Usage: "first settlement chain block of the processed commitments to compare with, if no reference database is given",
+	}
+
+	optionShadowDiffChainTo = &cli.Uint64Flag{
+		Name:  "chain-to",
+		Usage: "last settlement chain block of the processed commitments to compare with, if no reference database is given",
+	}
+)
+
+// shadowDiffCommand returns the command comparing the settlements recorded in
+// shadow mode with those of another oracle or of the oracle contract.
+func shadowDiffCommand() *cli.Command {
+	shadowFlags := []cli.Flag{
+		optionConfig,
+		optionPgHost,
+		optionPgPort,
+		optionPgUser,
+		optionPgPassword,
+		optionPgDbname,
+		optionSettlementRPCUrl,
+		optionOracleContractAddr,
+	}
+
+	return &cli.Command{
+		Name:  "shadow-diff",
+		Usage: "Compare the settlements recorded in shadow mode with another oracle or the processed commitments",
+		Flags: append([]cli.Flag{
+			optionShadowDiffFrom,
+			optionShadowDiffTo,
+			optionShadowDiffReferenceDB,
+			optionShadowDiffChainFrom,
+			optionShadowDiffChainTo,
+		}, shadowFlags...),
+		Before: altsrc.InitInputSourceWithContext(shadowFlags, altsrc.NewYamlSourceFromFlagFunc(optionConfig.Name)),
+		Action: func(c *cli.Context) error {
+			opts := &node.Options{
+				SettlementRPCUrl:   c.String(optionSettlementRPCUrl.Name),
+				OracleContractAddr: common.HexToAddress(c.String(optionOracleContractAddr.Name)),
+				PgHost:             c.String(optionPgHost.Name),
+				PgPort:             c.Int(optionPgPort.Name),
+				PgUser:             c.String(optionPgUser.Name),
+				PgPassword:         c.String(optionPgPassword.Name),
+				PgDbname:           c.String(optionPgDbname.Name),
+			}
+			from, to := c.Int64(optionShadowDiffFrom.Name), c.Int64(optionShadowDiffTo.Name)
+
+			var (
+				report settler.DiffReport
+				err    error
+			)
+			switch {
+			case c.IsSet(optionShadowDiffReferenceDB.Name):
+				report, err = node.ShadowDiff(c.Context, opts, from, to, c.String(optionShadowDiffReferenceDB.Name))
+			case c.IsSet(optionShadowDiffChainFrom.Name) && c.IsSet(optionShadowDiffChainTo.Name):
+				report, err = node.ShadowChainDiff(
+					c.Context,
+					opts,
+					from,
+					to,
+					c.Uint64(optionShadowDiffChainFrom.Name),
+					c.Uint64(optionShadowDiffChainTo.Name),
+				)
+			default:
+				return fmt.Errorf("either %s or both %s and %s are required",
+					optionShadowDiffReferenceDB.Name,
+					optionShadowDiffChainFrom.Name,
+					optionShadowDiffChainTo.Name,
+				)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to compare blocks %d to %d: %w", from, to, err)
+			}
+
+			enc := json.NewEncoder(c.App.Writer)
+			enc.SetIndent("", "  ")
+			return enc.Encode(report)
+		},
+	}
+}
@@ -3,6 +3,7 @@ package apiserver
 import (
 	"bufio"
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"expvar"
 	"log/slog"
@@ -10,6 +11,7 @@ import (
 	"net/http"
 	"net/http/pprof"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/primevprotocol/mev-oracle/pkg/store"
@@ -70,20 +72,7 @@ func (s *Service) registerDebugEndpoints() {
 
 func (s *Service) registerStatsEndpoints() {
 	s.router.HandleFunc("/processed_blocks", func(w http.ResponseWriter, r *http.Request) {
-		pg := r.URL.Query().Get("page")
-		lim := r.URL.Query().Get("limit")
-
-		page, limit := 0, 10
-		if pg != "" {
-			if pgInt, err := strconv.Atoi(pg); err == nil {
-				page = pgInt
-			}
-		}
-		if lim != "" {
-			if limInt, err := strconv.Atoi(lim); err == nil {
-				limit = limInt
-			}
-		}
+		page, limit := pagination(r)
 
 		blocks, err := s.storage.ProcessedBlocks(limit, page)
 		if err != nil {
@@ -92,45 +81,121 @@ func (s *Service) registerStatsEndpoints() {
 			return
 		}
 
-		resp, err := json.Marshal(blocks)
+		s.writeJSON(w, blocks)
+	})
+
+	s.router.HandleFunc("/disputed_blocks", func(w http.ResponseWriter, r *http.Request) {
+		page, limit := pagination(r)
+
+		blocks, err := s.storage.DisputedBlocks(limit, page)
 		if err != nil {
-			s.logger.Error("failed to marshal processed blocks", "error", err)
+			s.logger.Error("failed to get disputed blocks", "error", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		_, err = w.Write(resp)
+		s.writeJSON(w, blocks)
+	})
+
+	s.router.HandleFunc("/quarantined_blocks", func(w http.ResponseWriter, r *http.Request) {
+		page, limit := pagination(r)
+
+		blocks, err := s.storage.QuarantinedBlocks(limit, page)
 		if err != nil {
-			s.logger.Error("failed to write response", "error", err)
+			s.logger.Error("failed to get quarantined blocks", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
 		}
+
+		s.writeJSON(w, blocks)
 	})
 
-	s.router.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
-		stats, err := s.storage.CommitmentStats()
+	s.router.HandleFunc("/builder_mappings", func(w http.ResponseWriter, r *http.Request) {
+		page, limit := pagination(r)
+
+		mappings, err := s.storage.BuilderMappings(r.URL.Query().Get("builder"), limit, page)
 		if err != nil {
-			s.logger.Error("failed to get stats", "error", err)
+			s.logger.Error("failed to get builder mappings", "error", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
-		resp, err := json.Marshal(stats)
+		s.writeJSON(w, mappings)
+	})
+
+	s.router.HandleFunc("/settlements", func(w http.ResponseWriter, r *http.Request) {
+		var (
+			commitmentIdx []byte
+			blockNum      int64
+			err           error
+		)
+		if idx := r.URL.Query().Get("commitment_index"); idx != "" {
+			commitmentIdx, err = hex.DecodeString(strings.TrimPrefix(idx, "0x"))
+		} else {
+			blockNum, err = strconv.ParseInt(r.URL.Query().Get("block"), 10, 64)
+		}
+		if err != nil {
+			http.Error(w, "expected commitment_index or block parameter", http.StatusBadRequest)
+			return
+		}
+
+		settlements, err := s.storage.Settlements(commitmentIdx, blockNum)
 		if err != nil {
-			s.logger.Error("failed to marshal stats", "error", err)
+			s.logger.Error("failed to get settlements", "error", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		_, err = w.Write(resp)
+		s.writeJSON(w, settlements)
+	})
+
+	s.router.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
+		stats, err := s.storage.CommitmentStats()
 		if err != nil {
-			s.logger.Error("failed to write response", "error", err)
+			s.logger.Error("failed to get stats", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
 		}
+
+		s.writeJSON(w, stats)
 	})
 }
 
+// pagination returns the page and limit query parameters of the request.
+func pagination(r *http.Request) (page, limit int) {
+	pg := r.URL.Query().Get("page")
+	lim := r.URL.Query().Get("limit")
+
+	page, limit = 0, 10
+	if pg != "" {
+		if pgInt, err := strconv.Atoi(pg); err == nil {
+			page = pgInt
+		}
+	}
+	if lim != "" {
+		if limInt, err := strconv.Atoi(lim); err == nil {
+			limit = limInt
+		}
+	}
+	return page, limit
+}
+
+func (s *Service) writeJSON(w http.ResponseWriter, v any) {
+	resp, err := json.Marshal(v)
+	if err != nil {
+		s.logger.Error("failed to marshal response", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(resp)
+	if err != nil {
+		s.logger.Error("failed to write response", "error", err)
+	}
+}
+
 func newMetrics() (r *prometheus.Registry) {
 	r = prometheus.NewRegistry()
 
@@ -0,0 +1,19 @@
+package indexer
+
+import "time"
+
+func SetPollInterval(interval time.Duration) func() {
+	oldInterval := pollInterval
+	pollInterval = interval
+	return func() {
+		pollInterval = oldInterval
+	}
+}
+
+func SetMaxBlockRange(blocks uint64) func() {
+	oldBlocks := maxBlockRange
+	maxBlockRange = blocks
+	return func() {
+		maxBlockRange = oldBlocks
+	}
+}
@@ -0,0 +1,243 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	preconf "github.com/primevprotocol/contracts-abi/clients/PreConfCommitmentStore"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	pollInterval = time.Second
+	// maxBlockRange bounds the number of settlement chain blocks whose logs
+	// are fetched in a single request.
+	maxBlockRange uint64 = 1000
+)
+
+// DefaultCheckInterval is the default number of blocks read from the index
+// between two checks against the contract view.
+const DefaultCheckInterval = 100
+
+type Register interface {
+	// IndexerCursor returns the last settlement chain block indexed, or 0
+	// if none was.
+	IndexerCursor(ctx context.Context) (uint64, error)
+	// IndexCommitments replaces the commitment indexes of the L1 blocks and
+	// moves the cursor forward to the given settlement chain block.
+	IndexCommitments(ctx context.Context, commitments map[int64][][32]byte, cursor uint64) error
+	// CommitmentIndexes returns the commitment indexes of the L1 block in
+	// the order of the contract.
+	CommitmentIndexes(blockNum int64) ([][32]byte, error)
+}
+
+type EthClient interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+}
+
+// Preconf is the view of the commitments of the preconf contract.
+type Preconf interface {
+	GetCommitmentsByBlockNumber(blockNumber *big.Int) ([][32]byte, error)
+}
+
+// Indexer indexes the commitments of the L1 blocks as they are stored in the
+// preconf contract. The contract emits no event when storing a commitment,
+// but it verifies the bid signature on the way, so the SignatureVerified
+// events identify the L1 blocks whose commitments changed. The commitment
+// indexes of these blocks are then fetched from the contract and stored.
+type Indexer struct {
+	logger     *slog.Logger
+	client     EthClient
+	register   Register
+	preconf    Preconf
+	address    common.Address
+	startBlock uint64
+	filterer   *preconf.PreconfcommitmentstoreFilterer
+	eventID    common.Hash
+	metrics    *metrics
+	// mu serializes the syncs of the polling loop and of the readers
+	// catching up with the settlement chain head.
+	mu sync.Mutex
+}
+
+// NewIndexer returns an indexer of the preconf contract at the address. The
+// logs are indexed from the settlement chain start block on the first start
+// and from the last indexed block afterwards. The index is only complete if
+// it starts before the first commitment of the processed blocks was stored.
+func NewIndexer(
+	logger *slog.Logger,
+	client EthClient,
+	register Register,
+	preconfClient Preconf,
+	address common.Address,
+	startBlock uint64,
+) (*Indexer, error) {
+	filterer, err := preconf.NewPreconfcommitmentstoreFilterer(address, nil)
+	if err != nil {
+		return nil, err
+	}
+	contractABI, err := preconf.PreconfcommitmentstoreMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Indexer{
+		logger:     logger,
+		client:     client,
+		register:   register,
+		preconf:    preconfClient,
+		address:    address,
+		startBlock: startBlock,
+		filterer:   filterer,
+		eventID:    contractABI.Events["SignatureVerified"].ID,
+		metrics:    newMetrics(),
+	}, nil
+}
+
+func (i *Indexer) Metrics() []prometheus.Collector {
+	return i.metrics.Collectors()
+}
+
+// CatchUp ensures that the commitments stored up to the current settlement
+// chain head are indexed, indexing the blocks since the last poll if needed.
+// An error is returned if the index is more than maxBlockRange blocks behind
+// the head, as it is then still syncing and cannot catch up on a read.
+func (i *Indexer) CatchUp(ctx context.Context) error {
+	head, err := i.client.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get block number: %w", err)
+	}
+
+	cursor, err := i.register.IndexerCursor(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get cursor: %w", err)
+	}
+	if cursor >= head {
+		return nil
+	}
+	if head-cursor > maxBlockRange {
+		return fmt.Errorf("index at block %d too far behind head %d", cursor, head)
+	}
+
+	if err := i.sync(ctx); err != nil {
+		return err
+	}
+
+	cursor, err = i.register.IndexerCursor(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get cursor: %w", err)
+	}
+	if cursor < head {
+		return fmt.Errorf("index at block %d behind head %d", cursor, head)
+	}
+	return nil
+}
+
+func (i *Indexer) Start(ctx context.Context) <-chan struct{} {
+	doneChan := make(chan struct{})
+
+	go func() {
+		defer close(doneChan)
+
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := i.sync(ctx); err != nil {
+				i.logger.Error("failed to index commitments", "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return doneChan
+}
+
+// sync indexes the settlement chain blocks up to the head.
+func (i *Indexer) sync(ctx context.Context) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	head, err := i.client.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get block number: %w", err)
+	}
+
+	cursor, err := i.register.IndexerCursor(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get cursor: %w", err)
+	}
+
+	for from := max(cursor+1, i.startBlock); from <= head; {
+		to := min(from+maxBlockRange-1, head)
+		if err := i.index(ctx, from, to); err != nil {
+			return fmt.Errorf("failed to index blocks %d to %d: %w", from, to, err)
+		}
+		from = to + 1
+	}
+
+	i.metrics.IndexedBlock.Set(float64(head))
+	return nil
+}
+
+// index stores the commitment indexes of the L1 blocks for which commitments
+// were stored in the settlement chain blocks from to to.
+func (i *Indexer) index(ctx context.Context, from, to uint64) error {
+	logs, err := i.client.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(to),
+		Addresses: []common.Address{i.address},
+		Topics:    [][]common.Hash{{i.eventID}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to filter logs: %w", err)
+	}
+
+	commitments := make(map[int64][][32]byte)
+	for _, log := range logs {
+		event, err := i.filterer.ParseSignatureVerified(log)
+		if err != nil {
+			return fmt.Errorf("failed to parse log: %w", err)
+		}
+		commitments[int64(event.BlockNumber)] = nil
+	}
+
+	var count int
+	for blockNum := range commitments {
+		indexes, err := i.preconf.GetCommitmentsByBlockNumber(big.NewInt(blockNum))
+		if err != nil {
+			return fmt.Errorf("failed to get commitments of block %d: %w", blockNum, err)
+		}
+		commitments[blockNum] = indexes
+		count += len(indexes)
+	}
+
+	if err := i.register.IndexCommitments(ctx, commitments, to); err != nil {
+		return fmt.Errorf("failed to store commitments: %w", err)
+	}
+
+	if len(commitments) > 0 {
+		i.metrics.IndexedBlocksCount.Add(float64(len(commitments)))
+		i.logger.Debug(
+			"indexed commitments",
+			"from", from,
+			"to", to,
+			"blocks", len(commitments),
+			"commitments", count,
+		)
+	}
+	return nil
+}
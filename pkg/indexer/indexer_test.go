@@ -0,0 +1,265 @@
+package indexer_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	preconf "github.com/primevprotocol/contracts-abi/clients/PreConfCommitmentStore"
+	"github.com/primevprotocol/mev-oracle/pkg/indexer"
+)
+
+var preconfAddr = common.HexToAddress("0x1234")
+
+type testRegister struct {
+	mu          sync.Mutex
+	cursor      uint64
+	commitments map[int64][][32]byte
+}
+
+func (t *testRegister) IndexerCursor(ctx context.Context) (uint64, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.cursor, nil
+}
+
+func (t *testRegister) IndexCommitments(ctx context.Context, commitments map[int64][][32]byte, cursor uint64) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for blockNum, indexes := range commitments {
+		t.commitments[blockNum] = indexes
+	}
+	t.cursor = max(t.cursor, cursor)
+	return nil
+}
+
+func (t *testRegister) CommitmentIndexes(blockNum int64) ([][32]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.commitments[blockNum], nil
+}
+
+// testChain is a settlement chain with the commitments stored by the blocks
+// of the chain, and the preconf contract view of the resulting commitments.
+type testChain struct {
+	t           *testing.T
+	mu          sync.Mutex
+	head        uint64
+	logs        []types.Log
+	commitments map[int64][][32]byte
+	calls       map[int64]int
+}
+
+func (c *testChain) storeCommitment(blockNum int64, index [32]byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	contractABI, err := preconf.PreconfcommitmentstoreMetaData.GetAbi()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	event := contractABI.Events["SignatureVerified"]
+	data, err := event.Inputs.NonIndexed().Pack("abcd", uint64(blockNum))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+
+	c.head++
+	c.logs = append(c.logs, types.Log{
+		Address:     preconfAddr,
+		Topics:      []common.Hash{event.ID, common.HexToHash("0x01"), common.BigToHash(big.NewInt(100))},
+		Data:        data,
+		BlockNumber: c.head,
+	})
+	c.commitments[blockNum] = append(c.commitments[blockNum], index)
+}
+
+func (c *testChain) BlockNumber(ctx context.Context) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.head, nil
+}
+
+func (c *testChain) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var logs []types.Log
+	for _, log := range c.logs {
+		if log.BlockNumber >= q.FromBlock.Uint64() && log.BlockNumber <= q.ToBlock.Uint64() {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func (c *testChain) GetCommitmentsByBlockNumber(blockNumber *big.Int) ([][32]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls[blockNumber.Int64()]++
+	return append([][32]byte(nil), c.commitments[blockNumber.Int64()]...), nil
+}
+
+func (c *testChain) GetCommitment(commitmentIdx [32]byte) (preconf.PreConfCommitmentStorePreConfCommitment, error) {
+	return preconf.PreConfCommitmentStorePreConfCommitment{}, errors.New("not implemented")
+}
+
+func (c *testChain) GetCommitments(commitmentIdxs [][32]byte) ([]preconf.PreConfCommitmentStorePreConfCommitment, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *testChain) callCount(blockNum int64) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.calls[blockNum]
+}
+
+func TestIndexer(t *testing.T) {
+	// The index is only synced by the first poll and then by the reads.
+	defer indexer.SetPollInterval(time.Hour)()
+	defer indexer.SetMaxBlockRange(2)()
+
+	chain := &testChain{
+		t:           t,
+		commitments: make(map[int64][][32]byte),
+		calls:       make(map[int64]int),
+	}
+	register := &testRegister{commitments: make(map[int64][][32]byte)}
+
+	idx, err := indexer.NewIndexer(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		chain,
+		register,
+		chain,
+		preconfAddr,
+		0,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := indexer.NewIndexedPreconf(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		chain,
+		idx,
+		4,
+	)
+
+	chain.storeCommitment(10, [32]byte{1})
+	chain.storeCommitment(10, [32]byte{2})
+	chain.storeCommitment(11, [32]byte{3})
+	chain.storeCommitment(13, [32]byte{4})
+
+	// The contract is used while the index is too far behind the head to
+	// catch up on a read.
+	calls := chain.callCount(10)
+	if _, err := p.GetCommitmentsByBlockNumber(big.NewInt(10)); err != nil {
+		t.Fatal(err)
+	}
+	if chain.callCount(10) != calls+1 {
+		t.Fatal("expected the commitments to be read from the contract before the index is synced")
+	}
+	if cursor, _ := register.IndexerCursor(context.Background()); cursor != 0 {
+		t.Fatalf("expected the read not to sync the index, got cursor %d", cursor)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := idx.Start(ctx)
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	start := time.Now()
+	for {
+		cursor, _ := register.IndexerCursor(ctx)
+		if cursor == 4 {
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("timeout waiting for the index to sync")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for _, tc := range []struct {
+		blockNum int64
+		want     [][32]byte
+	}{
+		{blockNum: 10, want: [][32]byte{{1}, {2}}},
+		{blockNum: 11, want: [][32]byte{{3}}},
+		{blockNum: 12},
+	} {
+		blockNum, want := tc.blockNum, tc.want
+		calls := chain.callCount(blockNum)
+		got, err := p.GetCommitmentsByBlockNumber(big.NewInt(blockNum))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("block %d: expected %d commitments, got %d", blockNum, len(want), len(got))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("block %d: expected commitment %x, got %x", blockNum, want[i], got[i])
+			}
+		}
+		if chain.callCount(blockNum) != calls {
+			t.Fatalf("block %d: expected the commitments to be read from the index", blockNum)
+		}
+	}
+
+	t.Run("inconsistent", func(t *testing.T) {
+		// The commitment is stored in the contract without being indexed,
+		// every fourth block read from the index is checked against the
+		// contract.
+		register.mu.Lock()
+		register.commitments[13] = nil
+		register.mu.Unlock()
+
+		got, err := p.GetCommitmentsByBlockNumber(big.NewInt(13))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || got[0] != [32]byte{4} {
+			t.Fatalf("expected the contract view, got %x", got)
+		}
+
+		indexed, _ := register.CommitmentIndexes(13)
+		if len(indexed) != 1 || indexed[0] != [32]byte{4} {
+			t.Fatalf("expected the index to be repaired, got %x", indexed)
+		}
+	})
+
+	t.Run("stored after the last poll", func(t *testing.T) {
+		// The commitment is stored after the index was synced and before
+		// the next poll, the read catches up with the head instead of
+		// missing it.
+		chain.storeCommitment(12, [32]byte{5})
+
+		got, err := p.GetCommitmentsByBlockNumber(big.NewInt(12))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || got[0] != [32]byte{5} {
+			t.Fatalf("expected the new commitment, got %x", got)
+		}
+		if cursor, _ := register.IndexerCursor(ctx); cursor != 5 {
+			t.Fatalf("expected the index to catch up with the head, got cursor %d", cursor)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package indexer
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const (
+	defaultNamespace = "mev_commit_oracle"
+	subsystem        = "indexer"
+)
+
+type metrics struct {
+	IndexedBlock       prometheus.Gauge
+	IndexedBlocksCount prometheus.Counter
+	FallbackCount      prometheus.Counter
+	CheckCount         prometheus.Counter
+	InconsistencyCount prometheus.Counter
+}
+
+func newMetrics() *metrics {
+	m := &metrics{}
+	m.IndexedBlock = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "indexed_block",
+			Help:      "Last settlement chain block indexed",
+		},
+	)
+	m.IndexedBlocksCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "indexed_blocks_count",
+			Help:      "Number of times the commitments of an L1 block were indexed",
+		},
+	)
+	m.FallbackCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "fallback_count",
+			Help:      "Number of L1 blocks whose commitments were read from the contract as the index could not catch up",
+		},
+	)
+	m.CheckCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "check_count",
+			Help:      "Number of L1 blocks whose indexed commitments were checked against the contract",
+		},
+	)
+	m.InconsistencyCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "inconsistency_count",
+			Help:      "Number of L1 blocks whose indexed commitments differed from the contract",
+		},
+	)
+	return m
+}
+
+func (m *metrics) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.IndexedBlock,
+		m.IndexedBlocksCount,
+		m.FallbackCount,
+		m.CheckCount,
+		m.InconsistencyCount,
+	}
+}
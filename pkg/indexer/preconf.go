@@ -0,0 +1,102 @@
+package indexer
+
+import (
+	"context"
+	"log/slog"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/primevprotocol/mev-oracle/pkg/updater"
+)
+
+// IndexedPreconf reads the commitments of the L1 blocks from the index once
+// it caught up with the settlement chain head, so that no commitment stored
+// before the read is missing, and from the contract otherwise. In addition,
+// one in checkInterval blocks read from the index is checked against the
+// contract view, which is used and written to the index if they differ. A
+// zero checkInterval disables the checks.
+type IndexedPreconf struct {
+	updater.Preconf
+	logger        *slog.Logger
+	indexer       *Indexer
+	checkInterval int64
+	reads         atomic.Int64
+}
+
+func NewIndexedPreconf(
+	logger *slog.Logger,
+	preconfClient updater.Preconf,
+	indexer *Indexer,
+	checkInterval int,
+) *IndexedPreconf {
+	return &IndexedPreconf{
+		Preconf:       preconfClient,
+		logger:        logger,
+		indexer:       indexer,
+		checkInterval: int64(checkInterval),
+	}
+}
+
+func (p *IndexedPreconf) GetCommitmentsByBlockNumber(blockNumber *big.Int) ([][32]byte, error) {
+	if err := p.indexer.CatchUp(context.Background()); err != nil {
+		p.logger.Warn("commitment index not synced", "block", blockNumber, "error", err)
+		p.indexer.metrics.FallbackCount.Inc()
+		return p.Preconf.GetCommitmentsByBlockNumber(blockNumber)
+	}
+
+	indexes, err := p.indexer.register.CommitmentIndexes(blockNumber.Int64())
+	if err != nil {
+		p.logger.Warn("failed to read commitment index", "block", blockNumber, "error", err)
+		p.indexer.metrics.FallbackCount.Inc()
+		return p.Preconf.GetCommitmentsByBlockNumber(blockNumber)
+	}
+
+	if p.checkInterval > 0 && p.reads.Add(1)%p.checkInterval == 0 {
+		return p.check(blockNumber, indexes)
+	}
+	return indexes, nil
+}
+
+// check compares the indexed commitments of the block with the contract view
+// and returns the latter.
+func (p *IndexedPreconf) check(blockNumber *big.Int, indexes [][32]byte) ([][32]byte, error) {
+	p.indexer.metrics.CheckCount.Inc()
+
+	commitments, err := p.Preconf.GetCommitmentsByBlockNumber(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if equal(indexes, commitments) {
+		return commitments, nil
+	}
+
+	p.indexer.metrics.InconsistencyCount.Inc()
+	p.logger.Error(
+		"commitment index inconsistent with the contract",
+		"block", blockNumber,
+		"indexed", len(indexes),
+		"contract", len(commitments),
+	)
+
+	err = p.indexer.register.IndexCommitments(
+		context.Background(),
+		map[int64][][32]byte{blockNumber.Int64(): commitments},
+		0,
+	)
+	if err != nil {
+		p.logger.Error("failed to repair commitment index", "block", blockNumber, "error", err)
+	}
+	return commitments, nil
+}
+
+func equal(a, b [][32]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,404 @@
+package l1Client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	maxScore = 100
+	// errorPenalty is deducted from the score of an endpoint which failed a
+	// request.
+	errorPenalty = 20
+	// disagreementPenalty is deducted from the score of an endpoint which
+	// served data other providers did not agree on.
+	disagreementPenalty = 10
+)
+
+// A block or receipt not found is not penalized, as the endpoints lagging a
+// block behind the others are expected to report the latest ones missing.
+
+// ErrNoQuorum is returned if not enough endpoints agreed on the result.
+var ErrNoQuorum = errors.New("no quorum")
+
+// Client is the subset of the L1 RPC methods used by the oracle.
+type Client interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+	BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// Endpoint is an L1 RPC provider. The name is used in the logs and metrics, so
+// it should not contain any credentials.
+type Endpoint struct {
+	Name   string
+	Client Client
+}
+
+type endpoint struct {
+	Endpoint
+	score int
+}
+
+// MultiClient spreads the L1 requests over multiple endpoints. Each endpoint
+// has a health score which is lowered by failures and disagreements and slowly
+// recovers with successful requests.
+//
+// If the quorum is 1, the requests are sent to the healthiest endpoint and fail
+// over to the next ones on errors. Otherwise all the endpoints are queried and
+// the blocks, headers and receipts are only returned if at least quorum
+// endpoints agree on them. The quorum is then required to be a majority of the
+// endpoints, so that two different results cannot both reach it.
+type MultiClient struct {
+	logger    *slog.Logger
+	quorum    int
+	metrics   *metrics
+	mu        sync.Mutex
+	endpoints []*endpoint
+}
+
+func NewMultiClient(logger *slog.Logger, endpoints []Endpoint, quorum int) (*MultiClient, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("no endpoints")
+	}
+	if quorum < 1 || quorum > len(endpoints) {
+		return nil, fmt.Errorf("invalid quorum %d for %d endpoints", quorum, len(endpoints))
+	}
+	if quorum > 1 && quorum*2 <= len(endpoints) {
+		return nil, fmt.Errorf("quorum %d is not a majority of %d endpoints", quorum, len(endpoints))
+	}
+
+	m := &MultiClient{
+		logger:  logger,
+		quorum:  quorum,
+		metrics: newMetrics(),
+	}
+	for _, e := range endpoints {
+		m.endpoints = append(m.endpoints, &endpoint{Endpoint: e, score: maxScore})
+		m.metrics.EndpointScore.WithLabelValues(e.Name).Set(maxScore)
+	}
+	return m, nil
+}
+
+func (m *MultiClient) Metrics() []prometheus.Collector {
+	return m.metrics.Collectors()
+}
+
+// Scores returns the health scores of the endpoints by name.
+func (m *MultiClient) Scores() map[string]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	scores := make(map[string]int, len(m.endpoints))
+	for _, e := range m.endpoints {
+		scores[e.Name] = e.score
+	}
+	return scores
+}
+
+func (m *MultiClient) BlockNumber(ctx context.Context) (uint64, error) {
+	if m.quorum == 1 {
+		return failover(ctx, m, func(c Client) (uint64, error) {
+			return c.BlockNumber(ctx)
+		})
+	}
+	return m.quorumBlockNumber(ctx)
+}
+
+func (m *MultiClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if m.quorum == 1 {
+		return failover(ctx, m, func(c Client) (*types.Header, error) {
+			return c.HeaderByNumber(ctx, number)
+		})
+	}
+
+	number, err := m.quorumNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	results := query(ctx, m, func(c Client) (*types.Header, error) {
+		return c.HeaderByNumber(ctx, number)
+	})
+	return agree(m, results, (*types.Header).Hash)
+}
+
+func (m *MultiClient) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	if m.quorum == 1 {
+		return failover(ctx, m, func(c Client) (*types.Block, error) {
+			return c.BlockByNumber(ctx, number)
+		})
+	}
+
+	number, err := m.quorumNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	results := query(ctx, m, func(c Client) (*types.Block, error) {
+		return c.BlockByNumber(ctx, number)
+	})
+	return agree(m, results, (*types.Block).Hash)
+}
+
+func (m *MultiClient) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	if m.quorum == 1 {
+		return failover(ctx, m, func(c Client) (*types.Block, error) {
+			return c.BlockByHash(ctx, hash)
+		})
+	}
+
+	results := query(ctx, m, func(c Client) (*types.Block, error) {
+		return c.BlockByHash(ctx, hash)
+	})
+	return agree(m, results, (*types.Block).Hash)
+}
+
+func (m *MultiClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if m.quorum == 1 {
+		return failover(ctx, m, func(c Client) (*types.Receipt, error) {
+			return c.TransactionReceipt(ctx, txHash)
+		})
+	}
+
+	results := query(ctx, m, func(c Client) (*types.Receipt, error) {
+		return c.TransactionReceipt(ctx, txHash)
+	})
+	return agree(m, results, receiptHash)
+}
+
+// receiptHash identifies a receipt by its consensus fields and the block it
+// was included in.
+func receiptHash(r *types.Receipt) common.Hash {
+	// The encoding only fails for unsupported transaction types, which
+	// are then compared by their block and status alone.
+	data, _ := r.MarshalBinary()
+	return crypto.Keccak256Hash(data, r.BlockHash.Bytes(), []byte{byte(r.Status)})
+}
+
+// quorumBlockNumber returns the highest block number reached by at least
+// quorum endpoints.
+func (m *MultiClient) quorumBlockNumber(ctx context.Context) (uint64, error) {
+	results := query(ctx, m, func(c Client) (uint64, error) {
+		return c.BlockNumber(ctx)
+	})
+
+	var numbers []uint64
+	for _, r := range results {
+		if r.err != nil {
+			continue
+		}
+		numbers = append(numbers, r.val)
+	}
+	return m.quorumHeight(numbers, "block number")
+}
+
+// quorumHeight returns the highest of the block numbers reported by at least
+// quorum endpoints.
+func (m *MultiClient) quorumHeight(numbers []uint64, what string) (uint64, error) {
+	if len(numbers) < m.quorum {
+		m.metrics.QuorumFailureCount.Inc()
+		return 0, fmt.Errorf("%w: %d of %d endpoints returned the %s", ErrNoQuorum, len(numbers), m.quorum, what)
+	}
+
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] > numbers[j] })
+	return numbers[m.quorum-1], nil
+}
+
+// quorumNumber pins the latest block to the one reached by the quorum as the
+// providers are likely at different heads. The other tags, such as safe and
+// finalized, are pinned to the highest block the quorum reports for them, as
+// the providers do not update them at once either.
+func (m *MultiClient) quorumNumber(ctx context.Context, number *big.Int) (*big.Int, error) {
+	if number != nil && number.Sign() >= 0 {
+		return number, nil
+	}
+	if number == nil || number.Int64() == int64(rpc.LatestBlockNumber) {
+		n, err := m.quorumBlockNumber(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetUint64(n), nil
+	}
+
+	results := query(ctx, m, func(c Client) (*types.Header, error) {
+		return c.HeaderByNumber(ctx, number)
+	})
+
+	var numbers []uint64
+	for _, r := range results {
+		if r.err != nil {
+			continue
+		}
+		numbers = append(numbers, r.val.Number.Uint64())
+	}
+	n, err := m.quorumHeight(numbers, rpc.BlockNumber(number.Int64()).String()+" block")
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetUint64(n), nil
+}
+
+type result[T any] struct {
+	endpoint *endpoint
+	val      T
+	err      error
+}
+
+// ordered returns the endpoints sorted by their health, keeping the configured
+// order for equal scores.
+func (m *MultiClient) ordered() []*endpoint {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	endpoints := make([]*endpoint, len(m.endpoints))
+	copy(endpoints, m.endpoints)
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		return endpoints[i].score > endpoints[j].score
+	})
+	return endpoints
+}
+
+func (m *MultiClient) succeeded(e *endpoint) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e.score < maxScore {
+		e.score++
+		m.metrics.EndpointScore.WithLabelValues(e.Name).Set(float64(e.score))
+	}
+}
+
+// notFound records the block or receipt the endpoint did not find without
+// penalizing it.
+func (m *MultiClient) notFound(e *endpoint) {
+	m.metrics.EndpointErrorCount.WithLabelValues(e.Name, "not_found").Inc()
+}
+
+func (m *MultiClient) penalize(e *endpoint, penalty int, reason string, err error) {
+	m.mu.Lock()
+	e.score = max(e.score-penalty, 0)
+	score := e.score
+	m.mu.Unlock()
+
+	m.metrics.EndpointScore.WithLabelValues(e.Name).Set(float64(score))
+	m.metrics.EndpointErrorCount.WithLabelValues(e.Name, reason).Inc()
+	m.logger.Warn("l1 endpoint penalized", "endpoint", e.Name, "reason", reason, "score", score, "error", err)
+}
+
+// failover calls fn with the endpoints in the order of their health until one
+// of them succeeds.
+func failover[T any](ctx context.Context, m *MultiClient, fn func(Client) (T, error)) (T, error) {
+	var errs []error
+	for _, e := range m.ordered() {
+		val, err := fn(e.Client)
+		if err != nil {
+			if ctx.Err() != nil {
+				return val, ctx.Err()
+			}
+			if errors.Is(err, ethereum.NotFound) {
+				m.notFound(e)
+			} else {
+				m.penalize(e, errorPenalty, "error", err)
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", e.Name, err))
+			continue
+		}
+		m.succeeded(e)
+		return val, nil
+	}
+
+	var zero T
+	return zero, fmt.Errorf("all l1 endpoints failed: %w", errors.Join(errs...))
+}
+
+// query calls fn with all the endpoints concurrently.
+func query[T any](ctx context.Context, m *MultiClient, fn func(Client) (T, error)) []result[T] {
+	endpoints := m.ordered()
+	results := make([]result[T], len(endpoints))
+
+	var wg sync.WaitGroup
+	for i, e := range endpoints {
+		i, e := i, e
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			val, err := fn(e.Client)
+			results[i] = result[T]{endpoint: e, val: val, err: err}
+		}()
+	}
+	wg.Wait()
+
+	for _, r := range results {
+		switch {
+		case r.err == nil || ctx.Err() != nil:
+		case errors.Is(r.err, ethereum.NotFound):
+			m.notFound(r.endpoint)
+		default:
+			m.penalize(r.endpoint, errorPenalty, "error", r.err)
+		}
+	}
+	return results
+}
+
+// agree returns the value at least quorum endpoints agree on. As the quorum is
+// a majority, at most one value reaches it. The endpoints which served a
+// different value are penalized.
+func agree[T any](m *MultiClient, results []result[T], hash func(T) common.Hash) (T, error) {
+	var (
+		zero     T
+		votes    = make(map[common.Hash]int)
+		notFound int
+	)
+	for _, r := range results {
+		switch {
+		case r.err == nil:
+			votes[hash(r.val)]++
+		case errors.Is(r.err, ethereum.NotFound):
+			notFound++
+		}
+	}
+
+	var agreed *common.Hash
+	for h, count := range votes {
+		if count >= m.quorum {
+			h := h
+			agreed = &h
+			break
+		}
+	}
+	if agreed == nil {
+		if notFound >= m.quorum {
+			return zero, ethereum.NotFound
+		}
+		m.metrics.QuorumFailureCount.Inc()
+		return zero, fmt.Errorf("%w: %d distinct results from %d endpoints", ErrNoQuorum, len(votes), len(results))
+	}
+
+	var val T
+	for _, r := range results {
+		if r.err != nil {
+			continue
+		}
+		if hash(r.val) != *agreed {
+			m.penalize(r.endpoint, disagreementPenalty, "disagreement", nil)
+			continue
+		}
+		m.succeeded(r.endpoint)
+		val = r.val
+	}
+	return val, nil
+}
@@ -0,0 +1,328 @@
+package l1Client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/primevprotocol/mev-oracle/pkg/l1Client"
+)
+
+type testClient struct {
+	blockNumber uint64
+	finalized   uint64
+	extra       string
+	err         error
+}
+
+func (t *testClient) BlockNumber(_ context.Context) (uint64, error) {
+	return t.blockNumber, t.err
+}
+
+func (t *testClient) HeaderByNumber(_ context.Context, number *big.Int) (*types.Header, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	switch {
+	case number == nil:
+		number = new(big.Int).SetUint64(t.blockNumber)
+	case number.Int64() == int64(rpc.FinalizedBlockNumber):
+		number = new(big.Int).SetUint64(t.finalized)
+	}
+	if number.Uint64() > t.blockNumber {
+		return nil, ethereum.NotFound
+	}
+	return &types.Header{Number: number, Extra: []byte(t.extra)}, nil
+}
+
+func (t *testClient) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	header, err := t.HeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewBlockWithHeader(header), nil
+}
+
+func (t *testClient) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	for number := t.blockNumber; ; number-- {
+		block, err := t.BlockByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return nil, err
+		}
+		if block.Hash() == hash {
+			return block, nil
+		}
+		if number == 0 {
+			return nil, ethereum.NotFound
+		}
+	}
+}
+
+func (t *testClient) TransactionReceipt(_ context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	status := types.ReceiptStatusSuccessful
+	if t.extra == "reverted" {
+		status = types.ReceiptStatusFailed
+	}
+	return &types.Receipt{TxHash: txHash, Status: status, BlockNumber: new(big.Int).SetUint64(t.blockNumber)}, nil
+}
+
+func newMultiClient(t *testing.T, quorum int, clients ...*testClient) *l1Client.MultiClient {
+	t.Helper()
+
+	names := []string{"a", "b", "c"}
+	endpoints := make([]l1Client.Endpoint, 0, len(clients))
+	for i, c := range clients {
+		endpoints = append(endpoints, l1Client.Endpoint{Name: names[i], Client: c})
+	}
+
+	m, err := l1Client.NewMultiClient(slog.New(slog.NewTextHandler(io.Discard, nil)), endpoints, quorum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestFailover(t *testing.T) {
+	t.Parallel()
+
+	failing := &testClient{err: errors.New("unavailable")}
+	healthy := &testClient{blockNumber: 10}
+	m := newMultiClient(t, 1, failing, healthy)
+
+	blkNum, err := m.BlockNumber(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blkNum != 10 {
+		t.Fatalf("expected block number 10, got %d", blkNum)
+	}
+
+	scores := m.Scores()
+	if scores["a"] >= scores["b"] {
+		t.Fatalf("expected failing endpoint to be penalized, got %v", scores)
+	}
+
+	// the healthy endpoint is preferred once the failing one recovers
+	failing.err = nil
+	failing.blockNumber = 5
+	blkNum, err = m.BlockNumber(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blkNum != 10 {
+		t.Fatalf("expected block number 10, got %d", blkNum)
+	}
+
+	healthy.err = errors.New("unavailable")
+	failing.err = errors.New("unavailable")
+	_, err = m.HeaderByNumber(context.Background(), big.NewInt(1))
+	if err == nil {
+		t.Fatal("expected error if all endpoints fail")
+	}
+}
+
+func TestFailoverNotFound(t *testing.T) {
+	t.Parallel()
+
+	// the lagging endpoint is queried first, it is not penalized for the
+	// block it did not find yet and the request fails over
+	lagging := &testClient{blockNumber: 9}
+	synced := &testClient{blockNumber: 10}
+	m := newMultiClient(t, 1, lagging, synced)
+
+	header, err := m.HeaderByNumber(context.Background(), big.NewInt(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Number.Uint64() != 10 {
+		t.Fatalf("expected header 10, got %d", header.Number.Uint64())
+	}
+
+	scores := m.Scores()
+	if scores["a"] != 100 || scores["b"] != 100 {
+		t.Fatalf("expected lagging endpoint not to be penalized, got %v", scores)
+	}
+
+	_, err = m.HeaderByNumber(context.Background(), big.NewInt(11))
+	if !errors.Is(err, ethereum.NotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestQuorum(t *testing.T) {
+	t.Parallel()
+
+	t.Run("block number", func(t *testing.T) {
+		t.Parallel()
+
+		m := newMultiClient(t, 2,
+			&testClient{blockNumber: 10},
+			&testClient{blockNumber: 12},
+			&testClient{blockNumber: 11},
+		)
+
+		blkNum, err := m.BlockNumber(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if blkNum != 11 {
+			t.Fatalf("expected block number 11, got %d", blkNum)
+		}
+	})
+
+	t.Run("agreement", func(t *testing.T) {
+		t.Parallel()
+
+		m := newMultiClient(t, 2,
+			&testClient{blockNumber: 10, extra: "b1"},
+			&testClient{blockNumber: 10, extra: "wrong"},
+			&testClient{blockNumber: 10, extra: "b1"},
+		)
+
+		header, err := m.HeaderByNumber(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(header.Extra) != "b1" || header.Number.Uint64() != 10 {
+			t.Fatalf("unexpected header %v", header)
+		}
+
+		block, err := m.BlockByNumber(context.Background(), big.NewInt(9))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(block.Extra()) != "b1" {
+			t.Fatalf("unexpected block extra data %q", block.Extra())
+		}
+
+		byHash, err := m.BlockByHash(context.Background(), block.Hash())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if byHash.Hash() != block.Hash() {
+			t.Fatalf("expected block %s, got %s", block.Hash(), byHash.Hash())
+		}
+
+		scores := m.Scores()
+		if scores["b"] >= scores["a"] || scores["b"] >= scores["c"] {
+			t.Fatalf("expected disagreeing endpoint to be penalized, got %v", scores)
+		}
+	})
+
+	t.Run("finalized", func(t *testing.T) {
+		t.Parallel()
+
+		// The endpoints have not all seen the last finalized block yet.
+		m := newMultiClient(t, 2,
+			&testClient{blockNumber: 12, finalized: 8},
+			&testClient{blockNumber: 12, finalized: 10},
+			&testClient{blockNumber: 12, finalized: 9},
+		)
+
+		header, err := m.HeaderByNumber(context.Background(), big.NewInt(int64(rpc.FinalizedBlockNumber)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Number.Uint64() != 9 {
+			t.Fatalf("expected finalized header 9, got %d", header.Number.Uint64())
+		}
+
+		scores := m.Scores()
+		if scores["a"] != 100 || scores["b"] != 100 || scores["c"] != 100 {
+			t.Fatalf("expected no endpoint to be penalized, got %v", scores)
+		}
+	})
+
+	t.Run("no agreement", func(t *testing.T) {
+		t.Parallel()
+
+		m := newMultiClient(t, 2,
+			&testClient{blockNumber: 10, extra: "b1"},
+			&testClient{blockNumber: 10, extra: "b2"},
+			&testClient{err: errors.New("unavailable")},
+		)
+
+		_, err := m.HeaderByNumber(context.Background(), big.NewInt(10))
+		if !errors.Is(err, l1Client.ErrNoQuorum) {
+			t.Fatalf("expected no quorum error, got %v", err)
+		}
+	})
+
+	t.Run("receipt", func(t *testing.T) {
+		t.Parallel()
+
+		m := newMultiClient(t, 2,
+			&testClient{blockNumber: 10},
+			&testClient{blockNumber: 10, extra: "reverted"},
+			&testClient{blockNumber: 10},
+		)
+
+		receipt, err := m.TransactionReceipt(context.Background(), common.HexToHash("0x01"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			t.Fatalf("expected successful receipt, got status %d", receipt.Status)
+		}
+
+		scores := m.Scores()
+		if scores["b"] >= scores["a"] {
+			t.Fatalf("expected disagreeing endpoint to be penalized, got %v", scores)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+
+		m := newMultiClient(t, 2,
+			&testClient{blockNumber: 10},
+			&testClient{blockNumber: 10},
+			&testClient{blockNumber: 12},
+		)
+
+		_, err := m.HeaderByNumber(context.Background(), big.NewInt(12))
+		if !errors.Is(err, ethereum.NotFound) {
+			t.Fatalf("expected not found error, got %v", err)
+		}
+
+		scores := m.Scores()
+		if scores["a"] != 100 || scores["b"] != 100 {
+			t.Fatalf("expected lagging endpoints not to be penalized, got %v", scores)
+		}
+	})
+}
+
+func TestInvalidQuorum(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		endpoints int
+		quorum    int
+	}{
+		{endpoints: 1, quorum: 0},
+		{endpoints: 1, quorum: 2},
+		// two different results could both reach a quorum which is not a
+		// majority
+		{endpoints: 4, quorum: 2},
+	} {
+		var endpoints []l1Client.Endpoint
+		for i := 0; i < tc.endpoints; i++ {
+			endpoints = append(endpoints, l1Client.Endpoint{Name: fmt.Sprint(i), Client: &testClient{}})
+		}
+		_, err := l1Client.NewMultiClient(slog.New(slog.NewTextHandler(io.Discard, nil)), endpoints, tc.quorum)
+		if err == nil {
+			t.Fatalf("expected error for quorum %d of %d endpoints", tc.quorum, tc.endpoints)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package l1Client
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const (
+	defaultNamespace = "mev_commit_oracle"
+	subsystem        = "l1_client"
+)
+
+type metrics struct {
+	EndpointScore      *prometheus.GaugeVec
+	EndpointErrorCount *prometheus.CounterVec
+	QuorumFailureCount prometheus.Counter
+}
+
+func newMetrics() *metrics {
+	m := &metrics{}
+	m.EndpointScore = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "endpoint_score",
+			Help:      "Health score of the L1 endpoint",
+		},
+		[]string{"endpoint"},
+	)
+	m.EndpointErrorCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "endpoint_error_count",
+			Help:      "Number of failed, disagreeing or not found requests per L1 endpoint",
+		},
+		[]string{"endpoint", "reason"},
+	)
+	m.QuorumFailureCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "quorum_failure_count",
+			Help:      "Number of requests for which the L1 endpoints did not reach quorum",
+		},
+	)
+	return m
+}
+
+func (m *metrics) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.EndpointScore,
+		m.EndpointErrorCount,
+		m.QuorumFailureCount,
+	}
+}
@@ -0,0 +1,188 @@
+package l1Listener
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var beaconRequestTimeout = 5 * time.Second
+
+// SlotInfo is the beacon chain slot in which an L1 block was proposed.
+type SlotInfo struct {
+	Slot          uint64
+	ProposerIndex uint64
+	// MissedSlots are the slots without a block between the slot of the
+	// parent block and this one.
+	MissedSlots []uint64
+}
+
+// SlotResolver maps the L1 blocks to the beacon chain slots.
+type SlotResolver interface {
+	ResolveSlot(ctx context.Context, header *types.Header) (SlotInfo, error)
+}
+
+// errBeaconNotFound is returned if the beacon node has no block for the
+// requested slot or root.
+var errBeaconNotFound = errors.New("not found")
+
+// BeaconSlotResolver resolves the slots using the standard beacon node API.
+// The slot is derived from the block timestamp and verified against the
+// execution payload of the beacon block.
+type BeaconSlotResolver struct {
+	url    string
+	client *http.Client
+
+	mu             sync.Mutex
+	genesisTime    uint64
+	secondsPerSlot uint64
+}
+
+func NewBeaconSlotResolver(url string) *BeaconSlotResolver {
+	return &BeaconSlotResolver{
+		url:    strings.TrimSuffix(url, "/"),
+		client: &http.Client{Timeout: beaconRequestTimeout},
+	}
+}
+
+func (b *BeaconSlotResolver) ResolveSlot(ctx context.Context, header *types.Header) (SlotInfo, error) {
+	genesisTime, secondsPerSlot, err := b.chainConfig(ctx)
+	if err != nil {
+		return SlotInfo{}, err
+	}
+	if header.Time < genesisTime {
+		return SlotInfo{}, fmt.Errorf("block time %d before genesis %d", header.Time, genesisTime)
+	}
+	slot := (header.Time - genesisTime) / secondsPerSlot
+
+	var block struct {
+		Data struct {
+			Message struct {
+				ProposerIndex string      `json:"proposer_index"`
+				ParentRoot    common.Hash `json:"parent_root"`
+				Body          struct {
+					ExecutionPayloadHeader struct {
+						BlockHash common.Hash `json:"block_hash"`
+					} `json:"execution_payload_header"`
+				} `json:"body"`
+			} `json:"message"`
+		} `json:"data"`
+	}
+	if err := b.get(ctx, fmt.Sprintf("/eth/v1/beacon/blinded_blocks/%d", slot), &block); err != nil {
+		return SlotInfo{}, fmt.Errorf("failed to get beacon block of slot %d: %w", slot, err)
+	}
+
+	msg := block.Data.Message
+	if msg.Body.ExecutionPayloadHeader.BlockHash != header.Hash() {
+		return SlotInfo{}, fmt.Errorf(
+			"beacon block of slot %d has execution block %s, expected %s",
+			slot,
+			msg.Body.ExecutionPayloadHeader.BlockHash,
+			header.Hash(),
+		)
+	}
+
+	proposerIndex, err := strconv.ParseUint(msg.ProposerIndex, 10, 64)
+	if err != nil {
+		return SlotInfo{}, fmt.Errorf("invalid proposer index %q: %w", msg.ProposerIndex, err)
+	}
+
+	var parent struct {
+		Data struct {
+			Header struct {
+				Message struct {
+					Slot string `json:"slot"`
+				} `json:"message"`
+			} `json:"header"`
+		} `json:"data"`
+	}
+	if err := b.get(ctx, "/eth/v1/beacon/headers/"+msg.ParentRoot.Hex(), &parent); err != nil {
+		return SlotInfo{}, fmt.Errorf("failed to get parent of slot %d: %w", slot, err)
+	}
+	parentSlot, err := strconv.ParseUint(parent.Data.Header.Message.Slot, 10, 64)
+	if err != nil {
+		return SlotInfo{}, fmt.Errorf("invalid parent slot %q: %w", parent.Data.Header.Message.Slot, err)
+	}
+
+	info := SlotInfo{Slot: slot, ProposerIndex: proposerIndex}
+	for missed := parentSlot + 1; missed < slot; missed++ {
+		info.MissedSlots = append(info.MissedSlots, missed)
+	}
+	return info, nil
+}
+
+// chainConfig returns the genesis time and slot duration of the beacon chain.
+// They are fetched once and cached.
+func (b *BeaconSlotResolver) chainConfig(ctx context.Context) (uint64, uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.secondsPerSlot != 0 {
+		return b.genesisTime, b.secondsPerSlot, nil
+	}
+
+	var genesis struct {
+		Data struct {
+			GenesisTime string `json:"genesis_time"`
+		} `json:"data"`
+	}
+	if err := b.get(ctx, "/eth/v1/beacon/genesis", &genesis); err != nil {
+		return 0, 0, fmt.Errorf("failed to get genesis: %w", err)
+	}
+	genesisTime, err := strconv.ParseUint(genesis.Data.GenesisTime, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid genesis time %q: %w", genesis.Data.GenesisTime, err)
+	}
+
+	var spec struct {
+		Data struct {
+			SecondsPerSlot string `json:"SECONDS_PER_SLOT"`
+		} `json:"data"`
+	}
+	if err := b.get(ctx, "/eth/v1/config/spec", &spec); err != nil {
+		return 0, 0, fmt.Errorf("failed to get spec: %w", err)
+	}
+	secondsPerSlot, err := strconv.ParseUint(spec.Data.SecondsPerSlot, 10, 64)
+	if err != nil || secondsPerSlot == 0 {
+		return 0, 0, fmt.Errorf("invalid seconds per slot %q", spec.Data.SecondsPerSlot)
+	}
+
+	b.genesisTime, b.secondsPerSlot = genesisTime, secondsPerSlot
+	return genesisTime, secondsPerSlot, nil
+}
+
+func (b *BeaconSlotResolver) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.url+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return errBeaconNotFound
+	default:
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package l1Listener_test
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/primevprotocol/mev-oracle/pkg/l1Listener"
+)
+
+const (
+	testGenesisTime    = 1000
+	testSecondsPerSlot = 12
+)
+
+// newTestBeacon returns a beacon node stand-in serving the given execution
+// blocks by slot. The root of a block header encodes its slot, so parents maps
+// the slots to the slots of their parents.
+func newTestBeacon(t *testing.T, blocks map[uint64]common.Hash, parents map[uint64]uint64) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/eth/v1/beacon/genesis", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data":{"genesis_time":"%d"}}`, testGenesisTime)
+	})
+	mux.HandleFunc("/eth/v1/config/spec", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data":{"SECONDS_PER_SLOT":"%d"}}`, testSecondsPerSlot)
+	})
+	mux.HandleFunc("/eth/v1/beacon/blinded_blocks/", func(w http.ResponseWriter, r *http.Request) {
+		var slot uint64
+		if _, err := fmt.Sscanf(r.URL.Path, "/eth/v1/beacon/blinded_blocks/%d", &slot); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		blockHash, ok := blocks[slot]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(
+			w,
+			`{"data":{"message":{"slot":"%d","proposer_index":"%d","parent_root":"%s","body":{"execution_payload_header":{"block_hash":"%s"}}}}}`,
+			slot,
+			slot+1000,
+			common.BigToHash(new(big.Int).SetUint64(parents[slot])).Hex(),
+			blockHash.Hex(),
+		)
+	})
+	mux.HandleFunc("/eth/v1/beacon/headers/", func(w http.ResponseWriter, r *http.Request) {
+		root := common.HexToHash(r.URL.Path[len("/eth/v1/beacon/headers/"):])
+		fmt.Fprintf(w, `{"data":{"header":{"message":{"slot":"%d"}}}}`, root.Big().Uint64())
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestBeaconSlotResolver(t *testing.T) {
+	t.Parallel()
+
+	header := &types.Header{
+		Number: big.NewInt(5),
+		Time:   testGenesisTime + 10*testSecondsPerSlot,
+	}
+	srv := newTestBeacon(
+		t,
+		map[uint64]common.Hash{10: header.Hash()},
+		map[uint64]uint64{10: 7},
+	)
+
+	r := l1Listener.NewBeaconSlotResolver(srv.URL)
+
+	info, err := r.ResolveSlot(context.Background(), header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := l1Listener.SlotInfo{Slot: 10, ProposerIndex: 1010, MissedSlots: []uint64{8, 9}}
+	if diff := cmp.Diff(want, info); diff != "" {
+		t.Fatalf("unexpected slot info (-want +got):\n%s", diff)
+	}
+
+	// the block of the slot does not match the header
+	other := &types.Header{
+		Number: big.NewInt(6),
+		Time:   testGenesisTime + 10*testSecondsPerSlot,
+		Extra:  []byte("other"),
+	}
+	if _, err := r.ResolveSlot(context.Background(), other); err == nil {
+		t.Fatal("expected error for mismatching block")
+	}
+
+	// no block at the slot
+	missing := &types.Header{
+		Number: big.NewInt(7),
+		Time:   testGenesisTime + 11*testSecondsPerSlot,
+	}
+	if _, err := r.ResolveSlot(context.Background(), missing); err == nil {
+		t.Fatal("expected error for missing block")
+	}
+}
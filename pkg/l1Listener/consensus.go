@@ -0,0 +1,110 @@
+package l1Listener
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ConsensusPolicy decides how the winners reported by multiple sources are
+// reconciled.
+type ConsensusPolicy string
+
+const (
+	// ConsensusAny accepts the winner reported by the first source which
+	// determined one.
+	ConsensusAny ConsensusPolicy = "any"
+	// ConsensusMajority accepts the winner reported by more than half of the
+	// sources.
+	ConsensusMajority ConsensusPolicy = "majority"
+	// ConsensusAll accepts the winner only if all the sources agree on it.
+	ConsensusAll ConsensusPolicy = "all"
+)
+
+// NamedResolver is a WinnerResolver identified by the name of its source.
+type NamedResolver struct {
+	Name     string
+	Resolver WinnerResolver
+}
+
+// ConsensusResolver queries multiple winner sources and reconciles their
+// results according to the policy. If the sources do not reach consensus, the
+// resolution is marked as disputed with the leading candidate as the builder.
+type ConsensusResolver struct {
+	policy  ConsensusPolicy
+	sources []NamedResolver
+}
+
+func NewConsensusResolver(policy ConsensusPolicy, sources []NamedResolver) *ConsensusResolver {
+	return &ConsensusResolver{
+		policy:  policy,
+		sources: sources,
+	}
+}
+
+func (c *ConsensusResolver) ResolveWinner(ctx context.Context, header *types.Header) (Resolution, error) {
+	var (
+		candidates  = make(map[string]string, len(c.sources))
+		votes       = make(map[string]int)
+		resolutions = make(map[string]Resolution)
+		builders    []string
+	)
+
+	for _, source := range c.sources {
+		res, err := source.Resolver.ResolveWinner(ctx, header)
+		if err != nil {
+			return Resolution{}, fmt.Errorf("failed to resolve winner from %s: %w", source.Name, err)
+		}
+
+		candidates[source.Name] = res.Builder
+		if res.Builder == "" {
+			continue
+		}
+
+		votes[res.Builder]++
+		existing, ok := resolutions[res.Builder]
+		if !ok {
+			resolutions[res.Builder] = res
+			builders = append(builders, res.Builder)
+			continue
+		}
+		// Keep the details reported by any of the sources agreeing on the
+		// builder.
+		if existing.BuilderPubkey == "" {
+			existing.BuilderPubkey = res.BuilderPubkey
+		}
+		if existing.Relay == "" {
+			existing.Relay = res.Relay
+		}
+		resolutions[res.Builder] = existing
+	}
+
+	if len(builders) == 0 {
+		return Resolution{Candidates: candidates}, nil
+	}
+
+	// The leading candidate is the one with the most votes, ties are broken
+	// by the order of the sources.
+	leading := builders[0]
+	for _, builder := range builders[1:] {
+		if votes[builder] > votes[leading] {
+			leading = builder
+		}
+	}
+
+	var agreed bool
+	switch c.policy {
+	case ConsensusAny:
+		leading, agreed = builders[0], true
+	case ConsensusMajority:
+		agreed = votes[leading]*2 > len(c.sources)
+	default:
+		agreed = votes[leading] == len(c.sources)
+	}
+
+	res := resolutions[leading]
+	res.Candidates = candidates
+	res.Disputed = !agreed
+	return res, nil
+}
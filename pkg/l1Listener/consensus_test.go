@@ -0,0 +1,153 @@
+package l1Listener_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/primevprotocol/mev-oracle/pkg/l1Listener"
+)
+
+type staticResolver struct {
+	resolution l1Listener.Resolution
+	err        error
+}
+
+func (s staticResolver) ResolveWinner(_ context.Context, _ *types.Header) (l1Listener.Resolution, error) {
+	return s.resolution, s.err
+}
+
+func sources(builders ...string) []l1Listener.NamedResolver {
+	names := []string{"a", "b", "c"}
+	srcs := make([]l1Listener.NamedResolver, 0, len(builders))
+	for i, builder := range builders {
+		srcs = append(srcs, l1Listener.NamedResolver{
+			Name:     names[i],
+			Resolver: staticResolver{resolution: l1Listener.Resolution{Builder: builder}},
+		})
+	}
+	return srcs
+}
+
+func TestConsensusResolver(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		policy  l1Listener.ConsensusPolicy
+		sources []l1Listener.NamedResolver
+		want    l1Listener.Resolution
+	}{
+		{
+			name:    "all agree",
+			policy:  l1Listener.ConsensusAll,
+			sources: sources("b1", "b1", "b1"),
+			want: l1Listener.Resolution{
+				Builder:    "b1",
+				Candidates: map[string]string{"a": "b1", "b": "b1", "c": "b1"},
+			},
+		},
+		{
+			name:    "all disagree",
+			policy:  l1Listener.ConsensusAll,
+			sources: sources("b1", "b2", "b1"),
+			want: l1Listener.Resolution{
+				Builder:    "b1",
+				Disputed:   true,
+				Candidates: map[string]string{"a": "b1", "b": "b2", "c": "b1"},
+			},
+		},
+		{
+			name:    "majority",
+			policy:  l1Listener.ConsensusMajority,
+			sources: sources("b2", "b1", "b1"),
+			want: l1Listener.Resolution{
+				Builder:    "b1",
+				Candidates: map[string]string{"a": "b2", "b": "b1", "c": "b1"},
+			},
+		},
+		{
+			name:    "no majority",
+			policy:  l1Listener.ConsensusMajority,
+			sources: sources("b1", "b2", ""),
+			want: l1Listener.Resolution{
+				Builder:    "b1",
+				Disputed:   true,
+				Candidates: map[string]string{"a": "b1", "b": "b2", "c": ""},
+			},
+		},
+		{
+			name:    "any",
+			policy:  l1Listener.ConsensusAny,
+			sources: sources("", "b2", "b1"),
+			want: l1Listener.Resolution{
+				Builder:    "b2",
+				Candidates: map[string]string{"a": "", "b": "b2", "c": "b1"},
+			},
+		},
+		{
+			name:    "no winner",
+			policy:  l1Listener.ConsensusAll,
+			sources: sources("", ""),
+			want: l1Listener.Resolution{
+				Candidates: map[string]string{"a": "", "b": ""},
+			},
+		},
+		{
+			name:   "merged details",
+			policy: l1Listener.ConsensusAll,
+			sources: []l1Listener.NamedResolver{
+				{
+					Name:     "extra-data",
+					Resolver: staticResolver{resolution: l1Listener.Resolution{Builder: "b1"}},
+				},
+				{
+					Name: "relay",
+					Resolver: staticResolver{resolution: l1Listener.Resolution{
+						Builder:       "b1",
+						BuilderPubkey: "0xaa",
+						Relay:         "http://relay",
+					}},
+				},
+			},
+			want: l1Listener.Resolution{
+				Builder:       "b1",
+				BuilderPubkey: "0xaa",
+				Relay:         "http://relay",
+				Candidates:    map[string]string{"extra-data": "b1", "relay": "b1"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := l1Listener.NewConsensusResolver(tc.policy, tc.sources)
+			got, err := r.ResolveWinner(context.Background(), &types.Header{Number: big.NewInt(1)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("unexpected resolution (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("source error", func(t *testing.T) {
+		t.Parallel()
+
+		r := l1Listener.NewConsensusResolver(l1Listener.ConsensusAny, []l1Listener.NamedResolver{
+			{Name: "a", Resolver: staticResolver{resolution: l1Listener.Resolution{Builder: "b1"}}},
+			{Name: "b", Resolver: staticResolver{err: errors.New("unavailable")}},
+		})
+		_, err := r.ResolveWinner(context.Background(), &types.Header{Number: big.NewInt(1)})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
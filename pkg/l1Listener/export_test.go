@@ -9,3 +9,19 @@ func SetCheckInterval(interval time.Duration) func() {
 		checkInterval = oldInterval
 	}
 }
+
+func SetMaxBlocksPerTick(count int) func() {
+	oldCount := maxBlocksPerTick
+	maxBlocksPerTick = count
+	return func() {
+		maxBlocksPerTick = oldCount
+	}
+}
+
+func SetResubscribeInterval(interval time.Duration) func() {
+	oldInterval := resubscribeInterval
+	resubscribeInterval = interval
+	return func() {
+		resubscribeInterval = oldInterval
+	}
+}
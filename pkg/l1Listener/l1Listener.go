@@ -1,20 +1,84 @@
 package l1Listener
 
 import (
-	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"math/big"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-var checkInterval = 2 * time.Second
+var (
+	checkInterval = 2 * time.Second
+	// maxBlocksPerTick bounds the number of blocks processed on a single tick
+	// while catching up with the L1 head.
+	maxBlocksPerTick = 32
+	// maxReorgDepth is the number of blocks the listener walks back looking
+	// for the common ancestor once a reorg is detected.
+	maxReorgDepth = 64
+	// resubscribeInterval is the time the listener waits before subscribing
+	// to new heads again after the subscription failed.
+	resubscribeInterval = 30 * time.Second
+)
+
+// Finality selects the L1 block tag followed by the listener.
+type Finality string
+
+const (
+	// FinalityLatest follows the latest block, which can be reorged.
+	FinalityLatest Finality = "latest"
+	// FinalitySafe follows the block which is unlikely to be reorged.
+	FinalitySafe Finality = "safe"
+	// FinalityFinalized follows the block which can no longer be reorged.
+	FinalityFinalized Finality = "finalized"
+)
+
+// WinnerStatus is the status of the winner registered for an L1 block.
+type WinnerStatus string
+
+const (
+	// WinnerStatusOK is set for winners whose settlements can be processed.
+	WinnerStatusOK WinnerStatus = "ok"
+	// WinnerStatusDisputed is set if the winner sources disagreed. The
+	// settlements of the block are held until the dispute is resolved.
+	WinnerStatusDisputed WinnerStatus = "disputed"
+	// WinnerStatusNoWinner is set if no winner could be identified for the
+	// block. All the commitments of the block are returned.
+	WinnerStatusNoWinner WinnerStatus = "no_winner"
+)
+
+// Winner is the winner registered for an L1 block.
+type Winner struct {
+	BlockNumber   int64
+	BlockHash     common.Hash
+	ParentHash    common.Hash
+	Builder       string
+	BuilderPubkey string
+	Relay         string
+	ExtraData     []byte
+	Status        WinnerStatus
+	Candidates    map[string]string
+	// Slot is the beacon chain slot of the block, nil if unknown.
+	Slot *SlotInfo
+}
 
 type WinnerRegister interface {
-	RegisterWinner(ctx context.Context, blockNum int64, winner string) error
+	RegisterWinner(ctx context.Context, winner Winner) error
+	LastWinnerBlock(ctx context.Context) (int64, error)
+	// BlockHash returns the hash of the registered block. It returns
+	// ethereum.NotFound if the block was not registered.
+	BlockHash(ctx context.Context, blockNum int64) (common.Hash, error)
+	// MarkOrphaned marks the winners from the given block onwards and their
+	// pending settlements as orphaned and returns the number of settlements
+	// affected.
+	MarkOrphaned(ctx context.Context, fromBlock int64) (int, error)
 }
 
 type EthClient interface {
@@ -22,22 +86,44 @@ type EthClient interface {
 	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
 }
 
+// HeadSubscriber is implemented by clients which are able to push new L1
+// heads, like an ethclient.Client connected over websocket or IPC.
+type HeadSubscriber interface {
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+}
+
 type L1Listener struct {
 	logger         *slog.Logger
 	l1Client       EthClient
 	winnerRegister WinnerRegister
+	finality       Finality
+	headSubscriber HeadSubscriber
+	winnerResolver WinnerResolver
+	slotResolver   SlotResolver
 	metrics        *metrics
+
+	// Only accessed by the listener goroutine.
+	currentBlockNo uint64
+	currentHash    common.Hash
 }
 
 func NewL1Listener(
 	logger *slog.Logger,
 	l1Client EthClient,
 	winnerRegister WinnerRegister,
+	finality Finality,
+	headSubscriber HeadSubscriber,
+	winnerResolver WinnerResolver,
+	slotResolver SlotResolver,
 ) *L1Listener {
 	return &L1Listener{
 		logger:         logger,
 		l1Client:       l1Client,
 		winnerRegister: winnerRegister,
+		finality:       finality,
+		headSubscriber: headSubscriber,
+		winnerResolver: winnerResolver,
+		slotResolver:   slotResolver,
 		metrics:        newMetrics(),
 	}
 }
@@ -46,6 +132,9 @@ func (l *L1Listener) Metrics() []prometheus.Collector {
 	return l.metrics.Collectors()
 }
 
+// Start starts the listener. If a head subscriber is configured, the blocks
+// are processed as new heads are pushed and polling is only used while
+// catching up or after the subscription failed.
 func (l *L1Listener) Start(ctx context.Context) <-chan struct{} {
 	doneChan := make(chan struct{})
 
@@ -55,49 +144,358 @@ func (l *L1Listener) Start(ctx context.Context) <-chan struct{} {
 		ticker := time.NewTicker(checkInterval)
 		defer ticker.Stop()
 
-		currentBlockNo := 0
+		var (
+			headC        = make(chan *types.Header, 1)
+			sub          ethereum.Subscription
+			subErrC      <-chan error
+			resubscribeC <-chan time.Time
+			tickC        = ticker.C
+		)
+
+		subscribe := func() {
+			s, err := l.headSubscriber.SubscribeNewHead(ctx, headC)
+			if err != nil {
+				l.logger.Warn("failed to subscribe to new heads, polling instead", "error", err)
+				l.metrics.SubscriptionErrorCount.Inc()
+				resubscribeC = time.After(resubscribeInterval)
+				return
+			}
+			l.logger.Info("subscribed to new heads")
+			sub, subErrC, resubscribeC = s, s.Err(), nil
+		}
+		if l.headSubscriber != nil {
+			subscribe()
+		}
+		defer func() {
+			if sub != nil {
+				sub.Unsubscribe()
+			}
+		}()
+
+		checkpointLoaded := false
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case <-ticker.C:
-				blockNum, err := l.l1Client.BlockNumber(ctx)
-				if err != nil {
-					l.logger.Error("failed to get block number", "error", err)
-					continue
-				}
+			case err := <-subErrC:
+				l.logger.Warn("new heads subscription failed, polling instead", "error", err)
+				l.metrics.SubscriptionErrorCount.Inc()
+				sub.Unsubscribe()
+				sub, subErrC, tickC = nil, nil, ticker.C
+				resubscribeC = time.After(resubscribeInterval)
+				continue
+			case <-resubscribeC:
+				subscribe()
+				continue
+			case <-headC:
+			case <-tickC:
+			}
 
-				if blockNum <= uint64(currentBlockNo) {
-					continue
-				}
+			// Poll until the next event in case of failures, so that they
+			// are retried without waiting for the next head.
+			tickC = ticker.C
 
-				header, err := l.l1Client.HeaderByNumber(ctx, big.NewInt(int64(blockNum)))
-				if err != nil {
-					l.logger.Error("failed to get header", "block", blockNum, "error", err)
+			if !checkpointLoaded {
+				if err := l.loadCheckpoint(ctx); err != nil {
+					l.logger.Error("failed to load checkpoint", "error", err)
 					continue
 				}
+				checkpointLoaded = true
+			}
 
-				winner := string(bytes.ToValidUTF8(header.Extra, []byte("�")))
-				if len(winner) == 0 {
-					l.logger.Warn("no winner registered", "block", header.Number.Int64())
-					continue
-				} else {
-					err = l.winnerRegister.RegisterWinner(ctx, int64(blockNum), winner)
-					if err != nil {
-						l.logger.Error("failed to register winner for block", "block", blockNum, "error", err)
-						return
-					}
-
-					l.metrics.WinnerRoundCount.WithLabelValues(winner).Inc()
-					l.metrics.WinnerCount.Inc()
+			blockNum, err := l.headBlock(ctx)
+			if err != nil {
+				l.logger.Error("failed to get head block", "error", err)
+				continue
+			}
 
-					l.logger.Info("registered winner", "winner", winner, "block", header.Number.Int64())
-				}
-				currentBlockNo = int(blockNum)
+			if l.sync(ctx, blockNum) && sub != nil {
+				tickC = nil
 			}
 		}
-
 	}()
 
 	return doneChan
 }
+
+func (l *L1Listener) loadCheckpoint(ctx context.Context) error {
+	lastBlock, err := l.winnerRegister.LastWinnerBlock(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get last winner block: %w", err)
+	}
+	if lastBlock == 0 {
+		return nil
+	}
+
+	lastHash, err := l.winnerRegister.BlockHash(ctx, lastBlock)
+	switch {
+	case errors.Is(err, ethereum.NotFound):
+		// Blocks registered before hashes were recorded cannot be checked
+		// for reorgs.
+	case err != nil:
+		return fmt.Errorf("failed to get hash of block %d: %w", lastBlock, err)
+	}
+
+	l.logger.Info("resuming from last registered block", "block", lastBlock, "hash", lastHash)
+	l.currentBlockNo, l.currentHash = uint64(lastBlock), lastHash
+	return nil
+}
+
+// headBlock returns the number of the block the listener follows based on
+// the configured finality.
+func (l *L1Listener) headBlock(ctx context.Context) (uint64, error) {
+	latest, err := l.l1Client.BlockNumber(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get block number: %w", err)
+	}
+	l.metrics.LatestBlock.Set(float64(latest))
+
+	var tag rpc.BlockNumber
+	switch l.finality {
+	case FinalitySafe:
+		tag = rpc.SafeBlockNumber
+	case FinalityFinalized:
+		tag = rpc.FinalizedBlockNumber
+	default:
+		return latest, nil
+	}
+
+	header, err := l.l1Client.HeaderByNumber(ctx, big.NewInt(tag.Int64()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get %s header: %w", l.finality, err)
+	}
+
+	head := header.Number.Uint64()
+	if latest > head {
+		l.metrics.FinalityDistance.Set(float64(latest - head))
+	} else {
+		l.metrics.FinalityDistance.Set(0)
+	}
+	return head, nil
+}
+
+// sync processes the blocks following the last processed one up to the given
+// head, handling at most maxBlocksPerTick blocks per call. It reports whether
+// the listener caught up with the head.
+func (l *L1Listener) sync(ctx context.Context, head uint64) bool {
+	l.metrics.HeadBlock.Set(float64(head))
+
+	if head <= l.currentBlockNo {
+		return true
+	}
+
+	// Without a checkpoint there is nothing to catch up with, so we start
+	// from the current head.
+	if l.currentBlockNo == 0 {
+		l.currentBlockNo = head - 1
+	}
+
+	for i := 0; i < maxBlocksPerTick && l.currentBlockNo < head; i++ {
+		blockNum := l.currentBlockNo + 1
+		header, err := l.l1Client.HeaderByNumber(ctx, new(big.Int).SetUint64(blockNum))
+		if err != nil {
+			l.logger.Error("failed to get header", "block", blockNum, "error", err)
+			break
+		}
+
+		if l.currentHash != (common.Hash{}) && header.ParentHash != l.currentHash {
+			l.logger.Warn(
+				"reorg detected",
+				"block", blockNum,
+				"parentHash", header.ParentHash,
+				"expectedParentHash", l.currentHash,
+			)
+			if err := l.rollback(ctx); err != nil {
+				l.logger.Error("failed to handle reorg", "block", blockNum, "error", err)
+				break
+			}
+			continue
+		}
+
+		// The block is processed again on the next tick if its winner is
+		// not known yet, without moving the cursor past it.
+		err = l.processBlock(ctx, header)
+		if errors.Is(err, ErrWinnerPending) {
+			l.metrics.WinnerPendingCount.Inc()
+			l.logger.Info("winner not known yet, retrying", "block", blockNum)
+			break
+		}
+		if err != nil {
+			l.logger.Error("failed to process block", "block", blockNum, "error", err)
+			break
+		}
+
+		l.currentBlockNo, l.currentHash = blockNum, header.Hash()
+		l.metrics.LastProcessedBlock.Set(float64(blockNum))
+	}
+
+	l.metrics.BlocksBehind.Set(float64(head - l.currentBlockNo))
+	if l.currentBlockNo < head {
+		l.logger.Info(
+			"catching up with L1",
+			"current", l.currentBlockNo,
+			"head", head,
+			"behind", head-l.currentBlockNo,
+		)
+		return false
+	}
+	return true
+}
+
+// rollback walks back from the last processed block until it finds a
+// registered block which is still part of the canonical chain, orphans
+// everything registered after it and resets the listener to that block.
+func (l *L1Listener) rollback(ctx context.Context) error {
+	for depth := 0; depth < maxReorgDepth && l.currentBlockNo > uint64(depth); depth++ {
+		blockNum := l.currentBlockNo - uint64(depth)
+
+		storedHash, err := l.winnerRegister.BlockHash(ctx, int64(blockNum))
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get hash of block %d: %w", blockNum, err)
+		}
+
+		header, err := l.l1Client.HeaderByNumber(ctx, new(big.Int).SetUint64(blockNum))
+		if err != nil {
+			return fmt.Errorf("failed to get header %d: %w", blockNum, err)
+		}
+		if header.Hash() != storedHash {
+			continue
+		}
+
+		count, err := l.winnerRegister.MarkOrphaned(ctx, int64(blockNum+1))
+		if err != nil {
+			return fmt.Errorf("failed to mark blocks orphaned: %w", err)
+		}
+
+		l.metrics.ReorgCount.Inc()
+		l.metrics.LastReorgDepth.Set(float64(l.currentBlockNo - blockNum))
+
+		l.logger.Info(
+			"rolled back to common ancestor",
+			"block", blockNum,
+			"hash", storedHash,
+			"depth", l.currentBlockNo-blockNum,
+			"orphanedSettlements", count,
+		)
+
+		l.currentBlockNo, l.currentHash = blockNum, storedHash
+		return nil
+	}
+
+	return fmt.Errorf("no common ancestor found within %d blocks of %d", maxReorgDepth, l.currentBlockNo)
+}
+
+// Backfill registers the winners of the blocks in the range [from, to] which
+// are not registered yet. It does not move the checkpoint of the listener, so
+// it can be used while the listener is not started. The blocks whose winner is
+// not known yet are resolved again after the check interval. progress is
+// called after each block with whether the block was skipped.
+func (l *L1Listener) Backfill(
+	ctx context.Context,
+	from, to int64,
+	progress func(blockNum int64, skipped bool),
+) error {
+	for blockNum := from; blockNum <= to; blockNum++ {
+		_, err := l.winnerRegister.BlockHash(ctx, blockNum)
+		switch {
+		case err == nil:
+			progress(blockNum, true)
+			continue
+		case !errors.Is(err, ethereum.NotFound):
+			return fmt.Errorf("failed to check block %d: %w", blockNum, err)
+		}
+
+		header, err := l.l1Client.HeaderByNumber(ctx, big.NewInt(blockNum))
+		if err != nil {
+			return fmt.Errorf("failed to get header of block %d: %w", blockNum, err)
+		}
+
+		for {
+			err = l.processBlock(ctx, header)
+			if !errors.Is(err, ErrWinnerPending) {
+				break
+			}
+			l.metrics.WinnerPendingCount.Inc()
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(checkInterval):
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to process block %d: %w", blockNum, err)
+		}
+		progress(blockNum, false)
+	}
+	return nil
+}
+
+func (l *L1Listener) processBlock(ctx context.Context, header *types.Header) error {
+	resolution, err := l.winnerResolver.ResolveWinner(ctx, header)
+	if err != nil {
+		return fmt.Errorf("failed to resolve winner: %w", err)
+	}
+	winner := resolution.Builder
+
+	status := WinnerStatusOK
+	switch {
+	case resolution.Disputed:
+		status = WinnerStatusDisputed
+	case len(winner) == 0:
+		status = WinnerStatusNoWinner
+	}
+
+	// The slot is informational only, so the winner is registered even if
+	// it cannot be resolved.
+	var slot *SlotInfo
+	if l.slotResolver != nil {
+		info, err := l.slotResolver.ResolveSlot(ctx, header)
+		if err != nil {
+			l.metrics.SlotErrorCount.Inc()
+			l.logger.Warn("failed to resolve slot", "block", header.Number.Int64(), "error", err)
+		} else {
+			slot = &info
+			l.metrics.MissedSlotCount.Add(float64(len(info.MissedSlots)))
+		}
+	}
+
+	err = l.winnerRegister.RegisterWinner(ctx, Winner{
+		BlockNumber:   header.Number.Int64(),
+		BlockHash:     header.Hash(),
+		ParentHash:    header.ParentHash,
+		Builder:       winner,
+		BuilderPubkey: resolution.BuilderPubkey,
+		Relay:         resolution.Relay,
+		ExtraData:     header.Extra,
+		Status:        status,
+		Candidates:    resolution.Candidates,
+		Slot:          slot,
+	})
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case WinnerStatusDisputed:
+		l.metrics.DisputedCount.Inc()
+		l.logger.Warn(
+			"winner disputed",
+			"block", header.Number.Int64(),
+			"candidates", resolution.Candidates,
+		)
+		return nil
+	case WinnerStatusNoWinner:
+		l.metrics.NoWinnerCount.Inc()
+		l.logger.Warn("no winner identified", "block", header.Number.Int64())
+		return nil
+	}
+
+	l.metrics.WinnerRoundCount.WithLabelValues(winner).Inc()
+	l.metrics.WinnerCount.Inc()
+
+	l.logger.Info("registered winner", "winner", winner, "block", header.Number.Int64())
+	return nil
+}
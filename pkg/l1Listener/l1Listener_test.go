@@ -7,12 +7,16 @@ import (
 	"io"
 	"log/slog"
 	"math/big"
+	"net/http/httptest"
 	"sort"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/primevprotocol/mev-oracle/pkg/l1Listener"
 )
 
@@ -31,6 +35,10 @@ func TestL1Listener(t *testing.T) {
 		slog.New(slog.NewTextHandler(io.Discard, nil)),
 		ethClient,
 		reg,
+		l1Listener.FinalityLatest,
+		nil,
+		l1Listener.NewExtraDataResolver(nil),
+		nil,
 	)
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -63,6 +71,19 @@ func TestL1Listener(t *testing.T) {
 		Number: big.NewInt(10),
 	})
 
+	// the block is recorded without a winner
+	select {
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for winner")
+	case winner := <-reg.winners:
+		if winner.blockNum != 10 {
+			t.Fatal("wrong block number")
+		}
+		if winner.winner != "" || winner.status != l1Listener.WinnerStatusNoWinner {
+			t.Fatalf("expected no winner, got %v", winner)
+		}
+	}
+
 	// error registering winner, ensure it is retried
 	ethClient.errC <- errors.New("dummy error")
 	ethClient.AddHeader(11, &types.Header{
@@ -70,7 +91,6 @@ func TestL1Listener(t *testing.T) {
 		Extra:  []byte("b11"),
 	})
 
-	// ensure no winner is sent for the previous block
 	select {
 	case <-time.After(5 * time.Second):
 		t.Fatal("timeout waiting for winner")
@@ -91,32 +111,694 @@ func TestL1Listener(t *testing.T) {
 	}
 }
 
+func TestL1ListenerCatchup(t *testing.T) {
+	reg := &testRegister{
+		winners: make(chan winnerObj),
+	}
+	ethClient := &testEthClient{
+		headers: make(map[uint64]*types.Header),
+		errC:    make(chan error, 1),
+	}
+
+	l := l1Listener.NewL1Listener(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		ethClient,
+		reg,
+		l1Listener.FinalityLatest,
+		nil,
+		l1Listener.NewExtraDataResolver(nil),
+		nil,
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	t.Cleanup(l1Listener.SetCheckInterval(100 * time.Millisecond))
+	t.Cleanup(l1Listener.SetMaxBlocksPerTick(2))
+
+	done := l.Start(ctx)
+
+	ethClient.AddHeader(1, &types.Header{
+		Number: big.NewInt(1),
+		Extra:  []byte("b1"),
+	})
+
+	select {
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for winner")
+	case winner := <-reg.winners:
+		if winner.blockNum != 1 {
+			t.Fatal("wrong block number")
+		}
+	}
+
+	// multiple blocks arrive between ticks, all of them should be
+	// registered in order
+	for i := 2; i <= 10; i++ {
+		ethClient.AddHeader(uint64(i), &types.Header{
+			Number: big.NewInt(int64(i)),
+			Extra:  []byte(fmt.Sprintf("b%d", i)),
+		})
+	}
+
+	for i := 2; i <= 10; i++ {
+		select {
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for winner", i)
+		case winner := <-reg.winners:
+			if winner.blockNum != int64(i) {
+				t.Fatalf("wrong block number, expected %d got %d", i, winner.blockNum)
+			}
+			if winner.winner != fmt.Sprintf("b%d", i) {
+				t.Fatal("wrong winner")
+			}
+		}
+	}
+
+	cancel()
+	select {
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for done")
+	case <-done:
+	}
+}
+
+func TestL1ListenerResume(t *testing.T) {
+	reg := &testRegister{
+		winners:   make(chan winnerObj),
+		lastBlock: 5,
+	}
+	ethClient := &testEthClient{
+		headers: make(map[uint64]*types.Header),
+		errC:    make(chan error, 1),
+	}
+
+	for i := 1; i <= 8; i++ {
+		ethClient.AddHeader(uint64(i), &types.Header{
+			Number: big.NewInt(int64(i)),
+			Extra:  []byte(fmt.Sprintf("b%d", i)),
+		})
+	}
+
+	l := l1Listener.NewL1Listener(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		ethClient,
+		reg,
+		l1Listener.FinalityLatest,
+		nil,
+		l1Listener.NewExtraDataResolver(nil),
+		nil,
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	t.Cleanup(l1Listener.SetCheckInterval(100 * time.Millisecond))
+
+	done := l.Start(ctx)
+
+	for i := 6; i <= 8; i++ {
+		select {
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for winner", i)
+		case winner := <-reg.winners:
+			if winner.blockNum != int64(i) {
+				t.Fatalf("wrong block number, expected %d got %d", i, winner.blockNum)
+			}
+		}
+	}
+
+	cancel()
+	select {
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for done")
+	case <-done:
+	}
+}
+
+func TestL1ListenerBackfill(t *testing.T) {
+	t.Parallel()
+
+	reg := &testRegister{
+		winners: make(chan winnerObj, 10),
+		hashes:  map[int64]common.Hash{3: common.HexToHash("0x03")},
+	}
+	ethClient := &testEthClient{
+		headers: make(map[uint64]*types.Header),
+		errC:    make(chan error, 1),
+	}
+
+	for i := 1; i <= 6; i++ {
+		ethClient.AddHeader(uint64(i), &types.Header{
+			Number: big.NewInt(int64(i)),
+			Extra:  []byte(fmt.Sprintf("b%d", i)),
+		})
+	}
+
+	l := l1Listener.NewL1Listener(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		ethClient,
+		reg,
+		l1Listener.FinalityLatest,
+		nil,
+		l1Listener.NewExtraDataResolver(nil),
+		nil,
+	)
+
+	var skipped []int64
+	err := l.Backfill(context.Background(), 2, 5, func(blockNum int64, skip bool) {
+		if skip {
+			skipped = append(skipped, blockNum)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(skipped) != 1 || skipped[0] != 3 {
+		t.Fatalf("expected block 3 to be skipped, got %v", skipped)
+	}
+
+	close(reg.winners)
+	var registered []int64
+	for winner := range reg.winners {
+		if winner.winner != fmt.Sprintf("b%d", winner.blockNum) {
+			t.Fatalf("unexpected winner %v", winner)
+		}
+		registered = append(registered, winner.blockNum)
+	}
+	if fmt.Sprint(registered) != "[2 4 5]" {
+		t.Fatalf("unexpected registered blocks %v", registered)
+	}
+}
+
+func TestL1ListenerRelayPending(t *testing.T) {
+	t.Parallel()
+
+	reg := &testRegister{
+		winners: make(chan winnerObj, 1),
+	}
+	ethClient := &testEthClient{
+		headers: make(map[uint64]*types.Header),
+		errC:    make(chan error, 1),
+	}
+	header := &types.Header{Number: big.NewInt(1), Time: uint64(time.Now().Unix())}
+	ethClient.AddHeader(1, header)
+
+	relay := &testRelay{traces: make(map[string]string)}
+	relaySrv := httptest.NewServer(relay)
+	t.Cleanup(relaySrv.Close)
+
+	resolver, err := l1Listener.NewRelayResolver(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		[]string{relaySrv.URL},
+		map[string]string{"0xaa01": "builder1"},
+		time.Minute,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := l1Listener.NewL1Listener(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		ethClient,
+		reg,
+		l1Listener.FinalityLatest,
+		nil,
+		resolver,
+		nil,
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(l1Listener.SetCheckInterval(50 * time.Millisecond))
+	done := l.Start(ctx)
+
+	// the relay did not index the delivery yet, so the block is not
+	// registered until it does
+	start := time.Now()
+	for relay.requests.Load() < 3 {
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("timeout waiting for the relay to be queried")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case winner := <-reg.winners:
+		t.Fatalf("unexpected winner %v registered before the relay delivery", winner)
+	default:
+	}
+
+	relay.deliver(header.Hash().Hex(), "0xaa01")
+
+	select {
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for winner")
+	case winner := <-reg.winners:
+		if winner.blockNum != 1 || winner.winner != "builder1" || winner.status != l1Listener.WinnerStatusOK {
+			t.Fatalf("unexpected winner %v", winner)
+		}
+	}
+
+	cancel()
+	select {
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for done")
+	case <-done:
+	}
+}
+
+func TestL1ListenerRelayPendingTimeout(t *testing.T) {
+	t.Parallel()
+
+	reg := &testRegister{
+		winners:   make(chan winnerObj, 2),
+		lastBlock: 1,
+	}
+	ethClient := &testEthClient{
+		headers: make(map[uint64]*types.Header),
+		errC:    make(chan error, 1),
+	}
+	now := uint64(time.Now().Unix())
+	for blockNum := uint64(1); blockNum <= 3; blockNum++ {
+		ethClient.AddHeader(blockNum, &types.Header{Number: new(big.Int).SetUint64(blockNum), Time: now})
+	}
+
+	// block 2 is never delivered, block 3 is
+	relay := &testRelay{traces: make(map[string]string)}
+	relay.deliver(ethClient.headerHash(3).Hex(), "0xaa01")
+	relaySrv := httptest.NewServer(relay)
+	t.Cleanup(relaySrv.Close)
+
+	const pendingTimeout = time.Second
+	resolver, err := l1Listener.NewRelayResolver(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		[]string{relaySrv.URL},
+		map[string]string{"0xaa01": "builder1"},
+		pendingTimeout,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := l1Listener.NewL1Listener(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		ethClient,
+		reg,
+		l1Listener.FinalityLatest,
+		nil,
+		resolver,
+		nil,
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(l1Listener.SetCheckInterval(50 * time.Millisecond))
+	start := time.Now()
+	done := l.Start(ctx)
+
+	// the undelivered block holds back the next one for at most the
+	// pending timeout
+	for _, want := range []winnerObj{
+		{blockNum: 2, status: l1Listener.WinnerStatusNoWinner},
+		{blockNum: 3, winner: "builder1", status: l1Listener.WinnerStatusOK},
+	} {
+		select {
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for winner")
+		case winner := <-reg.winners:
+			if winner != want {
+				t.Fatalf("expected winner %v, got %v", want, winner)
+			}
+		}
+	}
+	if elapsed := time.Since(start); elapsed > pendingTimeout+time.Second {
+		t.Fatalf("expected the blocks to be registered within the pending timeout, took %s", elapsed)
+	}
+
+	cancel()
+	select {
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for done")
+	case <-done:
+	}
+}
+
+func TestL1ListenerReorg(t *testing.T) {
+	t.Parallel()
+
+	reg := &testRegister{
+		winners:  make(chan winnerObj),
+		orphaned: make(chan int64),
+	}
+	ethClient := &testEthClient{
+		headers: make(map[uint64]*types.Header),
+		errC:    make(chan error, 1),
+	}
+
+	l := l1Listener.NewL1Listener(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		ethClient,
+		reg,
+		l1Listener.FinalityLatest,
+		nil,
+		l1Listener.NewExtraDataResolver(nil),
+		nil,
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cl := l1Listener.SetCheckInterval(100 * time.Millisecond)
+	t.Cleanup(cl)
+
+	done := l.Start(ctx)
+
+	expectWinner := func(blockNum int64, builder string) {
+		t.Helper()
+		select {
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for winner", blockNum)
+		case winner := <-reg.winners:
+			if winner.blockNum != blockNum {
+				t.Fatalf("wrong block number, expected %d got %d", blockNum, winner.blockNum)
+			}
+			if winner.winner != builder {
+				t.Fatalf("wrong winner, expected %s got %s", builder, winner.winner)
+			}
+		}
+	}
+
+	for i := 1; i <= 4; i++ {
+		ethClient.AddHeader(uint64(i), &types.Header{
+			Number: big.NewInt(int64(i)),
+			Extra:  []byte(fmt.Sprintf("b%d", i)),
+		})
+		expectWinner(int64(i), fmt.Sprintf("b%d", i))
+	}
+
+	// blocks 3 and 4 are replaced by a new fork which is extended by block 5
+	for i := 3; i <= 5; i++ {
+		ethClient.AddHeader(uint64(i), &types.Header{
+			Number:     big.NewInt(int64(i)),
+			Extra:      []byte(fmt.Sprintf("r%d", i)),
+			ParentHash: ethClient.headerHash(uint64(i - 1)),
+		})
+	}
+
+	select {
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for orphaned blocks")
+	case fromBlock := <-reg.orphaned:
+		if fromBlock != 3 {
+			t.Fatalf("wrong orphaned block, expected 3 got %d", fromBlock)
+		}
+	}
+
+	for i := 3; i <= 5; i++ {
+		expectWinner(int64(i), fmt.Sprintf("r%d", i))
+	}
+
+	cancel()
+	select {
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for done")
+	case <-done:
+	}
+}
+
+func TestL1ListenerFinality(t *testing.T) {
+	t.Parallel()
+
+	reg := &testRegister{
+		winners: make(chan winnerObj),
+	}
+	ethClient := &testEthClient{
+		headers: make(map[uint64]*types.Header),
+		errC:    make(chan error, 1),
+	}
+
+	for i := 1; i <= 10; i++ {
+		ethClient.AddHeader(uint64(i), &types.Header{
+			Number: big.NewInt(int64(i)),
+			Extra:  []byte(fmt.Sprintf("b%d", i)),
+		})
+	}
+	ethClient.SetSafe(6)
+
+	l := l1Listener.NewL1Listener(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		ethClient,
+		reg,
+		l1Listener.FinalitySafe,
+		nil,
+		l1Listener.NewExtraDataResolver(nil),
+		nil,
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cl := l1Listener.SetCheckInterval(100 * time.Millisecond)
+	t.Cleanup(cl)
+
+	done := l.Start(ctx)
+
+	expectWinner := func(blockNum int64) {
+		t.Helper()
+		select {
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for winner", blockNum)
+		case winner := <-reg.winners:
+			if winner.blockNum != blockNum {
+				t.Fatalf("wrong block number, expected %d got %d", blockNum, winner.blockNum)
+			}
+		}
+	}
+
+	expectWinner(6)
+
+	// blocks past the safe block are not processed
+	select {
+	case winner := <-reg.winners:
+		t.Fatalf("unexpected winner for block %d", winner.blockNum)
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	ethClient.SetSafe(8)
+	expectWinner(7)
+	expectWinner(8)
+
+	cancel()
+	select {
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for done")
+	case <-done:
+	}
+}
+
+func TestL1ListenerSubscription(t *testing.T) {
+	reg := &testRegister{
+		winners: make(chan winnerObj),
+	}
+	ethClient := &testEthClient{
+		headers: make(map[uint64]*types.Header),
+		errC:    make(chan error, 1),
+	}
+	subscriber := &testSubscriber{
+		subs: make(chan *testSubscription, 1),
+	}
+
+	l := l1Listener.NewL1Listener(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		ethClient,
+		reg,
+		l1Listener.FinalityLatest,
+		subscriber,
+		l1Listener.NewExtraDataResolver(nil),
+		nil,
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// blocks should only be processed when new heads are pushed
+	t.Cleanup(l1Listener.SetCheckInterval(time.Hour))
+	t.Cleanup(l1Listener.SetResubscribeInterval(100 * time.Millisecond))
+
+	done := l.Start(ctx)
+
+	pushHeaders := func(sub *testSubscription, from, to int) {
+		t.Helper()
+		for i := from; i <= to; i++ {
+			hdr := &types.Header{
+				Number: big.NewInt(int64(i)),
+				Extra:  []byte(fmt.Sprintf("b%d", i)),
+			}
+			ethClient.AddHeader(uint64(i), hdr)
+			sub.headC <- hdr
+
+			select {
+			case <-time.After(5 * time.Second):
+				t.Fatal("timeout waiting for winner", i)
+			case winner := <-reg.winners:
+				if winner.blockNum != int64(i) {
+					t.Fatalf("wrong block number, expected %d got %d", i, winner.blockNum)
+				}
+			}
+		}
+	}
+
+	var sub *testSubscription
+	select {
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for subscription")
+	case sub = <-subscriber.subs:
+	}
+
+	pushHeaders(sub, 1, 3)
+
+	// the listener subscribes again after the subscription failed
+	sub.errC <- errors.New("connection lost")
+
+	select {
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for resubscription")
+	case sub = <-subscriber.subs:
+	}
+
+	pushHeaders(sub, 4, 5)
+
+	cancel()
+	select {
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for done")
+	case <-done:
+	}
+}
+
+func TestL1ListenerSubscriptionFallback(t *testing.T) {
+	reg := &testRegister{
+		winners: make(chan winnerObj),
+	}
+	ethClient := &testEthClient{
+		headers: make(map[uint64]*types.Header),
+		errC:    make(chan error, 1),
+	}
+	subscriber := &testSubscriber{
+		err: errors.New("notifications not supported"),
+	}
+
+	l := l1Listener.NewL1Listener(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		ethClient,
+		reg,
+		l1Listener.FinalityLatest,
+		subscriber,
+		l1Listener.NewExtraDataResolver(nil),
+		nil,
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	t.Cleanup(l1Listener.SetCheckInterval(100 * time.Millisecond))
+
+	done := l.Start(ctx)
+
+	for i := 1; i <= 3; i++ {
+		ethClient.AddHeader(uint64(i), &types.Header{
+			Number: big.NewInt(int64(i)),
+			Extra:  []byte(fmt.Sprintf("b%d", i)),
+		})
+
+		select {
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for winner", i)
+		case winner := <-reg.winners:
+			if winner.blockNum != int64(i) {
+				t.Fatalf("wrong block number, expected %d got %d", i, winner.blockNum)
+			}
+		}
+	}
+
+	cancel()
+	select {
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for done")
+	case <-done:
+	}
+}
+
 type winnerObj struct {
 	blockNum int64
 	winner   string
+	status   l1Listener.WinnerStatus
 }
 
 type testRegister struct {
-	winners chan winnerObj
+	winners   chan winnerObj
+	orphaned  chan int64
+	lastBlock int64
+
+	mu     sync.Mutex
+	hashes map[int64]common.Hash
+}
+
+func (t *testRegister) LastWinnerBlock(_ context.Context) (int64, error) {
+	return t.lastBlock, nil
 }
 
-func (t *testRegister) RegisterWinner(_ context.Context, blockNum int64, winner string) error {
-	t.winners <- winnerObj{blockNum: blockNum, winner: winner}
+func (t *testRegister) RegisterWinner(_ context.Context, winner l1Listener.Winner) error {
+	t.mu.Lock()
+	if t.hashes == nil {
+		t.hashes = make(map[int64]common.Hash)
+	}
+	t.hashes[winner.BlockNumber] = winner.BlockHash
+	t.mu.Unlock()
+
+	t.winners <- winnerObj{blockNum: winner.BlockNumber, winner: winner.Builder, status: winner.Status}
 	return nil
 }
 
+func (t *testRegister) BlockHash(_ context.Context, blockNum int64) (common.Hash, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	hash, ok := t.hashes[blockNum]
+	if !ok {
+		return common.Hash{}, ethereum.NotFound
+	}
+	return hash, nil
+}
+
+func (t *testRegister) MarkOrphaned(_ context.Context, fromBlock int64) (int, error) {
+	t.mu.Lock()
+	for blockNum := range t.hashes {
+		if blockNum >= fromBlock {
+			delete(t.hashes, blockNum)
+		}
+	}
+	t.mu.Unlock()
+
+	t.orphaned <- fromBlock
+	return 0, nil
+}
+
 type testEthClient struct {
 	mu      sync.Mutex
 	headers map[uint64]*types.Header
+	safe    uint64
 	errC    chan error
 }
 
+func (t *testEthClient) SetSafe(blockNum uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.safe = blockNum
+}
+
+// AddHeader adds the header to the chain. If the parent hash is not set, the
+// header is linked to the current header of the previous block.
 func (t *testEthClient) AddHeader(blockNum uint64, hdr *types.Header) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	if parent, ok := t.headers[blockNum-1]; ok && hdr.ParentHash == (common.Hash{}) {
+		hdr.ParentHash = parent.Hash()
+	}
 	t.headers[blockNum] = hdr
 }
 
+func (t *testEthClient) headerHash(blockNum uint64) common.Hash {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.headers[blockNum].Hash()
+}
+
 func (t *testEthClient) BlockNumber(_ context.Context) (uint64, error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -146,9 +828,44 @@ func (t *testEthClient) HeaderByNumber(_ context.Context, number *big.Int) (*typ
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
+	if number.Int64() == rpc.SafeBlockNumber.Int64() {
+		number = new(big.Int).SetUint64(t.safe)
+	}
+
 	hdr, ok := t.headers[number.Uint64()]
 	if !ok {
 		return nil, errors.New("header not found")
 	}
 	return hdr, nil
 }
+
+type testSubscriber struct {
+	subs chan *testSubscription
+	err  error
+}
+
+func (t *testSubscriber) SubscribeNewHead(
+	_ context.Context,
+	ch chan<- *types.Header,
+) (ethereum.Subscription, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	sub := &testSubscription{
+		headC: ch,
+		errC:  make(chan error, 1),
+	}
+	t.subs <- sub
+	return sub, nil
+}
+
+type testSubscription struct {
+	headC chan<- *types.Header
+	errC  chan error
+}
+
+func (t *testSubscription) Unsubscribe() {}
+
+func (t *testSubscription) Err() <-chan error {
+	return t.errC
+}
@@ -8,8 +8,21 @@ const (
 )
 
 type metrics struct {
-	WinnerRoundCount *prometheus.CounterVec
-	WinnerCount      prometheus.Counter
+	WinnerRoundCount       *prometheus.CounterVec
+	WinnerCount            prometheus.Counter
+	LatestBlock            prometheus.Gauge
+	HeadBlock              prometheus.Gauge
+	FinalityDistance       prometheus.Gauge
+	LastProcessedBlock     prometheus.Gauge
+	BlocksBehind           prometheus.Gauge
+	ReorgCount             prometheus.Counter
+	LastReorgDepth         prometheus.Gauge
+	SubscriptionErrorCount prometheus.Counter
+	DisputedCount          prometheus.Counter
+	NoWinnerCount          prometheus.Counter
+	WinnerPendingCount     prometheus.Counter
+	MissedSlotCount        prometheus.Counter
+	SlotErrorCount         prometheus.Counter
 }
 
 func newMetrics() *metrics {
@@ -31,6 +44,110 @@ func newMetrics() *metrics {
 			Help:      "Number of times a provider won",
 		},
 	)
+	m.LatestBlock = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "latest_block",
+			Help:      "Latest L1 block number seen by the listener",
+		},
+	)
+	m.HeadBlock = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "head_block",
+			Help:      "L1 block number followed by the listener based on the finality mode",
+		},
+	)
+	m.FinalityDistance = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "finality_distance",
+			Help:      "Number of blocks between the latest L1 block and the followed block",
+		},
+	)
+	m.LastProcessedBlock = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "last_processed_block",
+			Help:      "Last L1 block number processed by the listener",
+		},
+	)
+	m.BlocksBehind = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "blocks_behind",
+			Help:      "Number of L1 blocks the listener is behind the followed block",
+		},
+	)
+	m.ReorgCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "reorg_count",
+			Help:      "Number of L1 reorgs handled",
+		},
+	)
+	m.LastReorgDepth = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "last_reorg_depth",
+			Help:      "Number of blocks rolled back on the last L1 reorg",
+		},
+	)
+	m.SubscriptionErrorCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "subscription_error_count",
+			Help:      "Number of times subscribing to new L1 heads failed",
+		},
+	)
+	m.DisputedCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "disputed_count",
+			Help:      "Number of blocks for which the winner sources disagreed",
+		},
+	)
+	m.NoWinnerCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "no_winner_count",
+			Help:      "Number of blocks for which no winner could be identified",
+		},
+	)
+	m.WinnerPendingCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "winner_pending_count",
+			Help:      "Number of times a block was resolved again as its winner was not known yet",
+		},
+	)
+	m.MissedSlotCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "missed_slot_count",
+			Help:      "Number of beacon chain slots missed before the processed blocks",
+		},
+	)
+	m.SlotErrorCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "slot_error_count",
+			Help:      "Number of blocks for which the beacon chain slot could not be resolved",
+		},
+	)
 	return m
 }
 
@@ -38,5 +155,18 @@ func (m *metrics) Collectors() []prometheus.Collector {
 	return []prometheus.Collector{
 		m.WinnerRoundCount,
 		m.WinnerCount,
+		m.LatestBlock,
+		m.HeadBlock,
+		m.FinalityDistance,
+		m.LastProcessedBlock,
+		m.BlocksBehind,
+		m.ReorgCount,
+		m.LastReorgDepth,
+		m.SubscriptionErrorCount,
+		m.DisputedCount,
+		m.NoWinnerCount,
+		m.WinnerPendingCount,
+		m.MissedSlotCount,
+		m.SlotErrorCount,
 	}
 }
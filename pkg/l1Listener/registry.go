@@ -0,0 +1,128 @@
+package l1Listener
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/yaml.v3"
+)
+
+// BuilderConfig is the entry of a builder in the registry. Aliases are matched
+// case insensitively against the whole raw value while patterns are regular
+// expressions matched against any part of it. FeeRecipients are the addresses
+// the builder sets as the fee recipient of its blocks.
+type BuilderConfig struct {
+	Name          string   `yaml:"name"`
+	Aliases       []string `yaml:"aliases"`
+	Patterns      []string `yaml:"patterns"`
+	FeeRecipients []string `yaml:"fee_recipients"`
+}
+
+type builderPattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// BuilderRegistry canonicalizes the raw values builders put into the blocks,
+// like the extra data or the fee recipient, into the builder names registered
+// in the oracle contract.
+type BuilderRegistry struct {
+	aliases       map[string]string
+	patterns      []builderPattern
+	feeRecipients map[common.Address]string
+}
+
+func NewBuilderRegistry(builders []BuilderConfig) (*BuilderRegistry, error) {
+	r := &BuilderRegistry{
+		aliases:       make(map[string]string),
+		feeRecipients: make(map[common.Address]string),
+	}
+	for _, b := range builders {
+		if b.Name == "" {
+			return nil, fmt.Errorf("builder without name")
+		}
+		for _, alias := range append([]string{b.Name}, b.Aliases...) {
+			key := normalizeAlias(alias)
+			if name, ok := r.aliases[key]; ok && name != b.Name {
+				return nil, fmt.Errorf("alias %q of %s already used by %s", alias, b.Name, name)
+			}
+			r.aliases[key] = b.Name
+		}
+		for _, pattern := range b.Patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern of %s: %w", b.Name, err)
+			}
+			r.patterns = append(r.patterns, builderPattern{name: b.Name, re: re})
+		}
+		for _, feeRecipient := range b.FeeRecipients {
+			if !common.IsHexAddress(feeRecipient) {
+				return nil, fmt.Errorf("invalid fee recipient %q of %s", feeRecipient, b.Name)
+			}
+			addr := common.HexToAddress(feeRecipient)
+			if name, ok := r.feeRecipients[addr]; ok && name != b.Name {
+				return nil, fmt.Errorf("fee recipient %s of %s already used by %s", addr, b.Name, name)
+			}
+			r.feeRecipients[addr] = b.Name
+		}
+	}
+	return r, nil
+}
+
+// LoadBuilderRegistry reads the registry from a YAML file listing the builders
+// under the builders key.
+func LoadBuilderRegistry(path string) (*BuilderRegistry, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg struct {
+		Builders []BuilderConfig `yaml:"builders"`
+	}
+	if err := yaml.Unmarshal(buf, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse builder registry: %w", err)
+	}
+	return NewBuilderRegistry(cfg.Builders)
+}
+
+// Canonicalize returns the name of the builder matching the raw value. Aliases
+// take precedence over patterns, which are tried in the order of the registry.
+// The raw value is returned if no builder matches or the registry is nil.
+func (r *BuilderRegistry) Canonicalize(raw string) string {
+	if r == nil {
+		return raw
+	}
+	if name, ok := r.aliases[normalizeAlias(raw)]; ok {
+		return name
+	}
+	for _, p := range r.patterns {
+		if p.re.MatchString(raw) {
+			return p.name
+		}
+	}
+	return raw
+}
+
+// FeeRecipientBuilder returns the name of the builder using the fee
+// recipient, if any.
+func (r *BuilderRegistry) FeeRecipientBuilder(feeRecipient common.Address) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	name, ok := r.feeRecipients[feeRecipient]
+	return name, ok
+}
+
+// HasFeeRecipients reports whether the fee recipients of any builder are
+// registered.
+func (r *BuilderRegistry) HasFeeRecipients() bool {
+	return r != nil && len(r.feeRecipients) > 0
+}
+
+func normalizeAlias(alias string) string {
+	return strings.ToLower(strings.TrimSpace(alias))
+}
@@ -0,0 +1,118 @@
+package l1Listener_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/primevprotocol/mev-oracle/pkg/l1Listener"
+)
+
+func TestBuilderRegistry(t *testing.T) {
+	t.Parallel()
+
+	registryFile := filepath.Join(t.TempDir(), "builders.yaml")
+	err := os.WriteFile(registryFile, []byte(`
+builders:
+  - name: beaverbuild
+    aliases:
+      - beaverbuild.org
+    fee_recipients:
+      - '0x95222290DD7278Aa3Ddd389Cc1E1d165CC4BAfe5'
+  - name: titan
+    aliases:
+      - Titan (titanbuilder.xyz)
+    patterns:
+      - titanbuilder
+  - name: rsync
+    patterns:
+      - '^rsync-builder(\.xyz)?( v[0-9.]+)?$'
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry, err := l1Listener.LoadBuilderRegistry(registryFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{raw: "beaverbuild", want: "beaverbuild"},
+		{raw: "beaverbuild.org", want: "beaverbuild"},
+		{raw: " BeaverBuild.org ", want: "beaverbuild"},
+		{raw: "Titan (titanbuilder.xyz)", want: "titan"},
+		{raw: "titanbuilder.xyz v2", want: "titan"},
+		{raw: "rsync-builder.xyz v1.2", want: "rsync"},
+		{raw: "rsync-builder", want: "rsync"},
+		{raw: "unknown builder", want: "unknown builder"},
+	}
+
+	for _, tc := range tests {
+		if got := registry.Canonicalize(tc.raw); got != tc.want {
+			t.Errorf("canonicalize %q: expected %q, got %q", tc.raw, tc.want, got)
+		}
+	}
+
+	name, ok := registry.FeeRecipientBuilder(common.HexToAddress("0x95222290dd7278aa3ddd389cc1e1d165cc4bafe5"))
+	if !ok || name != "beaverbuild" {
+		t.Errorf("expected fee recipient of beaverbuild, got %q", name)
+	}
+	if name, ok := registry.FeeRecipientBuilder(common.HexToAddress("0x01")); ok {
+		t.Errorf("unexpected builder %q of unknown fee recipient", name)
+	}
+
+	var nilRegistry *l1Listener.BuilderRegistry
+	if got := nilRegistry.Canonicalize("beaverbuild.org"); got != "beaverbuild.org" {
+		t.Errorf("expected raw value from nil registry, got %q", got)
+	}
+	if nilRegistry.HasFeeRecipients() || !registry.HasFeeRecipients() {
+		t.Error("unexpected fee recipients of the registries")
+	}
+}
+
+func TestBuilderRegistryInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		builders []l1Listener.BuilderConfig
+	}{
+		{
+			name:     "missing name",
+			builders: []l1Listener.BuilderConfig{{Aliases: []string{"b1"}}},
+		},
+		{
+			name:     "invalid pattern",
+			builders: []l1Listener.BuilderConfig{{Name: "b1", Patterns: []string{"("}}},
+		},
+		{
+			name:     "invalid fee recipient",
+			builders: []l1Listener.BuilderConfig{{Name: "b1", FeeRecipients: []string{"0x01"}}},
+		},
+		{
+			name: "duplicate fee recipient",
+			builders: []l1Listener.BuilderConfig{
+				{Name: "b1", FeeRecipients: []string{common.HexToAddress("0x01").Hex()}},
+				{Name: "b2", FeeRecipients: []string{common.HexToAddress("0x01").Hex()}},
+			},
+		},
+		{
+			name: "duplicate alias",
+			builders: []l1Listener.BuilderConfig{
+				{Name: "b1", Aliases: []string{"builder"}},
+				{Name: "b2", Aliases: []string{"Builder"}},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		if _, err := l1Listener.NewBuilderRegistry(tc.builders); err == nil {
+			t.Errorf("%s: expected error", tc.name)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package l1Listener
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	relayRequestTimeout = 5 * time.Second
+	// relayCacheSize is the number of block resolutions kept in memory.
+	relayCacheSize = 1024
+)
+
+// DefaultRelayPendingTimeout is the default age of a block after which the
+// relays not reporting its delivery are trusted to never report it. The
+// listener registers no later block meanwhile, so it is kept close to the
+// delay of the relays indexing the deliveries, about a slot.
+const DefaultRelayPendingTimeout = 12 * time.Second
+
+const proposerPayloadDeliveredPath = "/relay/v1/data/bidtraces/proposer_payload_delivered"
+
+// bidTrace is the subset of the relay data API bid trace used to determine the
+// winner of a block.
+type bidTrace struct {
+	BlockHash     string `json:"block_hash"`
+	BuilderPubkey string `json:"builder_pubkey"`
+}
+
+// RelayResolver determines the winner of a block by querying the data API of
+// the MEV-Boost relays for the payload delivered to the proposer. The builder
+// public key reported by the relay is mapped to the name of the registered
+// builder. If the public key is not known, it is used as the name of the
+// winner. The relays index the deliveries with some delay, so the blocks
+// younger than the pending timeout which none of the relays delivered are
+// reported as pending rather than without a winner.
+type RelayResolver struct {
+	logger         *slog.Logger
+	relays         []string
+	builderPubkeys map[string]string
+	pendingTimeout time.Duration
+	client         *http.Client
+
+	mu         sync.Mutex
+	cache      map[common.Hash]Resolution
+	cacheOrder []common.Hash
+}
+
+// NewRelayResolver creates a new RelayResolver querying the given relays in
+// order. builderPubkeys maps the builder BLS public keys to builder names. At
+// least one relay is required, as every block would be reported without a
+// winner otherwise.
+func NewRelayResolver(
+	logger *slog.Logger,
+	relays []string,
+	builderPubkeys map[string]string,
+	pendingTimeout time.Duration,
+) (*RelayResolver, error) {
+	if len(relays) == 0 {
+		return nil, errors.New("no relays")
+	}
+
+	pubkeys := make(map[string]string, len(builderPubkeys))
+	for pubkey, name := range builderPubkeys {
+		pubkeys[strings.ToLower(pubkey)] = name
+	}
+
+	return &RelayResolver{
+		logger:         logger,
+		relays:         relays,
+		builderPubkeys: pubkeys,
+		pendingTimeout: pendingTimeout,
+		client:         &http.Client{Timeout: relayRequestTimeout},
+		cache:          make(map[common.Hash]Resolution),
+	}, nil
+}
+
+// ResolveWinner returns the winner reported by the first relay which delivered
+// the block. If none of the relays delivered it, ErrWinnerPending is returned
+// until the block is older than the pending timeout and an empty resolution
+// afterwards. An error is returned if none of the relays could be queried.
+func (r *RelayResolver) ResolveWinner(ctx context.Context, header *types.Header) (Resolution, error) {
+	blockHash := header.Hash()
+	if resolution, ok := r.cached(blockHash); ok {
+		return resolution, nil
+	}
+
+	var errs []error
+	for _, relay := range r.relays {
+		trace, err := r.payloadDelivered(ctx, relay, blockHash)
+		if err != nil {
+			r.logger.Warn("failed to query relay", "relay", relay, "block", header.Number, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+		if trace == nil {
+			continue
+		}
+
+		pubkey := strings.ToLower(trace.BuilderPubkey)
+		builder, ok := r.builderPubkeys[pubkey]
+		if !ok {
+			r.logger.Warn("unknown builder public key", "relay", relay, "pubkey", pubkey)
+			builder = pubkey
+		}
+
+		resolution := Resolution{
+			Builder:       builder,
+			BuilderPubkey: pubkey,
+			Relay:         relay,
+		}
+		r.store(blockHash, resolution)
+		return resolution, nil
+	}
+
+	if len(errs) == len(r.relays) && len(errs) > 0 {
+		return Resolution{}, fmt.Errorf("failed to query relays: %w", errors.Join(errs...))
+	}
+
+	// Not cached as the relays might not have indexed the delivery yet.
+	if time.Since(time.Unix(int64(header.Time), 0)) < r.pendingTimeout {
+		return Resolution{}, ErrWinnerPending
+	}
+	return Resolution{}, nil
+}
+
+func (r *RelayResolver) payloadDelivered(
+	ctx context.Context,
+	relay string,
+	blockHash common.Hash,
+) (*bidTrace, error) {
+	u, err := url.Parse(strings.TrimSuffix(relay, "/") + proposerPayloadDeliveredPath)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = url.Values{"block_hash": []string{blockHash.Hex()}}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var traces []bidTrace
+	if err := json.NewDecoder(resp.Body).Decode(&traces); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	for _, trace := range traces {
+		if common.HexToHash(trace.BlockHash) == blockHash {
+			return &trace, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *RelayResolver) cached(blockHash common.Hash) (Resolution, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	resolution, ok := r.cache[blockHash]
+	return resolution, ok
+}
+
+func (r *RelayResolver) store(blockHash common.Hash, resolution Resolution) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.cache[blockHash]; ok {
+		return
+	}
+	if len(r.cacheOrder) >= relayCacheSize {
+		delete(r.cache, r.cacheOrder[0])
+		r.cacheOrder = r.cacheOrder[1:]
+	}
+	r.cache[blockHash] = resolution
+	r.cacheOrder = append(r.cacheOrder, blockHash)
+}
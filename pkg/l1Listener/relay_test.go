@@ -0,0 +1,170 @@
+package l1Listener_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/primevprotocol/mev-oracle/pkg/l1Listener"
+)
+
+type testRelay struct {
+	mu       sync.Mutex
+	traces   map[string]string
+	requests atomic.Int32
+}
+
+// deliver makes the relay report the delivery of the block by the builder.
+func (t *testRelay) deliver(blockHash, pubkey string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.traces[blockHash] = pubkey
+}
+
+func (t *testRelay) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t.requests.Add(1)
+
+	if r.URL.Path != "/relay/v1/data/bidtraces/proposer_payload_delivered" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	blockHash := r.URL.Query().Get("block_hash")
+	traces := []map[string]string{}
+	t.mu.Lock()
+	pubkey, ok := t.traces[blockHash]
+	t.mu.Unlock()
+	if ok {
+		traces = append(traces, map[string]string{
+			"slot":           "100",
+			"block_hash":     blockHash,
+			"block_number":   "5",
+			"builder_pubkey": pubkey,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(traces)
+}
+
+func TestRelayResolver(t *testing.T) {
+	t.Parallel()
+
+	header1 := &types.Header{Number: big.NewInt(5), Extra: []byte("b1")}
+	header2 := &types.Header{Number: big.NewInt(6), Extra: []byte("b2")}
+	header3 := &types.Header{Number: big.NewInt(7), Extra: []byte("b3")}
+
+	failingRelay := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(failingRelay.Close)
+
+	relay1 := &testRelay{traces: map[string]string{
+		header1.Hash().Hex(): "0xAA01",
+	}}
+	relay1Srv := httptest.NewServer(relay1)
+	t.Cleanup(relay1Srv.Close)
+
+	relay2 := &testRelay{traces: map[string]string{
+		header2.Hash().Hex(): "0xaa02",
+	}}
+	relay2Srv := httptest.NewServer(relay2)
+	t.Cleanup(relay2Srv.Close)
+
+	resolver, err := l1Listener.NewRelayResolver(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		[]string{failingRelay.URL, relay1Srv.URL, relay2Srv.URL},
+		map[string]string{
+			"0xaa01": "builder1",
+		},
+		time.Minute,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolution, err := resolver.ResolveWinner(context.Background(), header1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolution.Builder != "builder1" {
+		t.Fatalf("expected builder1, got %q", resolution.Builder)
+	}
+	if resolution.BuilderPubkey != "0xaa01" {
+		t.Fatalf("expected pubkey 0xaa01, got %q", resolution.BuilderPubkey)
+	}
+	if resolution.Relay != relay1Srv.URL {
+		t.Fatalf("expected relay %s, got %q", relay1Srv.URL, resolution.Relay)
+	}
+
+	// unknown builders are reported by their public key
+	resolution, err = resolver.ResolveWinner(context.Background(), header2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolution.Builder != "0xaa02" || resolution.Relay != relay2Srv.URL {
+		t.Fatalf("unexpected resolution %+v", resolution)
+	}
+
+	// recent blocks not delivered by any relay are pending, as the relays
+	// might not have indexed the delivery yet
+	recent := &types.Header{Number: big.NewInt(8), Time: uint64(time.Now().Unix())}
+	_, err = resolver.ResolveWinner(context.Background(), recent)
+	if !errors.Is(err, l1Listener.ErrWinnerPending) {
+		t.Fatalf("expected pending winner, got %v", err)
+	}
+
+	// older blocks not delivered by any relay have no winner
+	resolution, err = resolver.ResolveWinner(context.Background(), header3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolution.Builder != "" {
+		t.Fatalf("expected no winner, got %q", resolution.Builder)
+	}
+
+	// resolved blocks are served from the cache
+	requests := relay1.requests.Load()
+	resolution, err = resolver.ResolveWinner(context.Background(), header1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolution.Builder != "builder1" {
+		t.Fatalf("expected builder1, got %q", resolution.Builder)
+	}
+	if relay1.requests.Load() != requests {
+		t.Fatal("expected cached resolution")
+	}
+
+	// an error is returned only if all the relays failed
+	failing, err := l1Listener.NewRelayResolver(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		[]string{failingRelay.URL},
+		nil,
+		time.Minute,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = failing.ResolveWinner(context.Background(), header1)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	// without relays every block would be reported without a winner
+	_, err = l1Listener.NewRelayResolver(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, nil, time.Minute)
+	if err == nil {
+		t.Fatal("expected error without relays")
+	}
+}
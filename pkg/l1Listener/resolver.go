@@ -0,0 +1,90 @@
+package l1Listener
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Resolution is the winner of an L1 block as determined by a WinnerResolver.
+type Resolution struct {
+	// Builder is the name of the winning builder, it is empty if no winner
+	// could be determined for the block.
+	Builder string
+	// BuilderPubkey is the BLS public key of the winning builder, if known.
+	BuilderPubkey string
+	// Relay is the URL of the relay which delivered the block, if known.
+	Relay string
+	// Disputed is set if multiple sources did not agree on the winner.
+	Disputed bool
+	// Candidates are the winners reported by each of the sources, if
+	// multiple sources were queried.
+	Candidates map[string]string
+}
+
+// ErrWinnerPending is returned by the resolvers whose sources might still
+// determine the winner of the block later. The block is resolved again
+// instead of being registered without a winner.
+var ErrWinnerPending = errors.New("winner not known yet")
+
+// WinnerResolver determines the builder which won an L1 block.
+type WinnerResolver interface {
+	ResolveWinner(ctx context.Context, header *types.Header) (Resolution, error)
+}
+
+// ExtraDataResolver uses the extra data set by the builder in the block header
+// as the name of the winner. The extra data is canonicalized by the registry,
+// if any.
+type ExtraDataResolver struct {
+	registry *BuilderRegistry
+}
+
+func NewExtraDataResolver(registry *BuilderRegistry) *ExtraDataResolver {
+	return &ExtraDataResolver{registry: registry}
+}
+
+func (r *ExtraDataResolver) ResolveWinner(_ context.Context, header *types.Header) (Resolution, error) {
+	extraData := string(bytes.ToValidUTF8(header.Extra, []byte("�")))
+	if extraData == "" {
+		return Resolution{}, nil
+	}
+	return Resolution{Builder: r.registry.Canonicalize(extraData)}, nil
+}
+
+// FeeRecipientResolver uses the name of the builder registered with the fee
+// recipient (coinbase) of the block as the winner, or the fee recipient
+// itself if the registry, if any, does not know it.
+type FeeRecipientResolver struct {
+	registry *BuilderRegistry
+}
+
+func NewFeeRecipientResolver(registry *BuilderRegistry) *FeeRecipientResolver {
+	return &FeeRecipientResolver{registry: registry}
+}
+
+func (r *FeeRecipientResolver) ResolveWinner(_ context.Context, header *types.Header) (Resolution, error) {
+	if name, ok := r.registry.FeeRecipientBuilder(header.Coinbase); ok {
+		return Resolution{Builder: name}, nil
+	}
+	return Resolution{Builder: header.Coinbase.Hex()}, nil
+}
+
+// OverrideResolver assigns the configured winners to the blocks in a round
+// robin manner. It is meant for testing only.
+type OverrideResolver struct {
+	winners []string
+}
+
+func NewOverrideResolver(winners []string) *OverrideResolver {
+	return &OverrideResolver{winners: winners}
+}
+
+func (r *OverrideResolver) ResolveWinner(_ context.Context, header *types.Header) (Resolution, error) {
+	if len(r.winners) == 0 {
+		return Resolution{}, nil
+	}
+	idx := header.Number.Int64() % int64(len(r.winners))
+	return Resolution{Builder: r.winners[idx]}, nil
+}
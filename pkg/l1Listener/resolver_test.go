@@ -0,0 +1,112 @@
+package l1Listener_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/primevprotocol/mev-oracle/pkg/l1Listener"
+)
+
+func TestWinnerResolvers(t *testing.T) {
+	t.Parallel()
+
+	header := &types.Header{
+		Number:   big.NewInt(5),
+		Coinbase: common.HexToAddress("0xabcd"),
+		Extra:    []byte("beaverbuild.org"),
+	}
+
+	registry, err := l1Listener.NewBuilderRegistry([]l1Listener.BuilderConfig{
+		{
+			Name:          "beaverbuild",
+			Aliases:       []string{"beaverbuild.org"},
+			FeeRecipients: []string{header.Coinbase.Hex()},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		resolver l1Listener.WinnerResolver
+		header   *types.Header
+		want     string
+	}{
+		{
+			name:     "extra data",
+			resolver: l1Listener.NewExtraDataResolver(nil),
+			header:   header,
+			want:     "beaverbuild.org",
+		},
+		{
+			name:     "empty extra data",
+			resolver: l1Listener.NewExtraDataResolver(nil),
+			header:   &types.Header{Number: big.NewInt(5)},
+			want:     "",
+		},
+		{
+			name:     "invalid utf8 extra data",
+			resolver: l1Listener.NewExtraDataResolver(nil),
+			header:   &types.Header{Number: big.NewInt(5), Extra: []byte{'b', 0xff}},
+			want:     "b�",
+		},
+		{
+			name:     "canonicalized extra data",
+			resolver: l1Listener.NewExtraDataResolver(registry),
+			header:   header,
+			want:     "beaverbuild",
+		},
+		{
+			name:     "fee recipient",
+			resolver: l1Listener.NewFeeRecipientResolver(nil),
+			header:   header,
+			want:     common.HexToAddress("0xabcd").Hex(),
+		},
+		{
+			name:     "registered fee recipient",
+			resolver: l1Listener.NewFeeRecipientResolver(registry),
+			header:   header,
+			want:     "beaverbuild",
+		},
+		{
+			name:     "unregistered fee recipient",
+			resolver: l1Listener.NewFeeRecipientResolver(registry),
+			header:   &types.Header{Number: big.NewInt(5), Coinbase: common.HexToAddress("0x1234")},
+			want:     common.HexToAddress("0x1234").Hex(),
+		},
+		{
+			name: "fee recipient agreeing with extra data",
+			resolver: l1Listener.NewConsensusResolver(l1Listener.ConsensusAll, []l1Listener.NamedResolver{
+				{Name: "extra-data", Resolver: l1Listener.NewExtraDataResolver(registry)},
+				{Name: "fee-recipient", Resolver: l1Listener.NewFeeRecipientResolver(registry)},
+			}),
+			header: header,
+			want:   "beaverbuild",
+		},
+		{
+			name:     "override",
+			resolver: l1Listener.NewOverrideResolver([]string{"b0", "b1", "b2"}),
+			header:   header,
+			want:     "b2",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			resolution, err := tc.resolver.ResolveWinner(context.Background(), tc.header)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resolution.Builder != tc.want {
+				t.Fatalf("expected winner %q, got %q", tc.want, resolution.Builder)
+			}
+		})
+	}
+}
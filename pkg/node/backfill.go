@@ -0,0 +1,330 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	rollupclient "github.com/primevprotocol/contracts-abi/clients/Oracle"
+	preconf "github.com/primevprotocol/contracts-abi/clients/PreConfCommitmentStore"
+	"github.com/primevprotocol/mev-oracle/pkg/l1Listener"
+	"github.com/primevprotocol/mev-oracle/pkg/settler"
+	"github.com/primevprotocol/mev-oracle/pkg/store"
+	"github.com/primevprotocol/mev-oracle/pkg/updater"
+)
+
+const (
+	// backfillReportInterval is the number of blocks between the progress
+	// reports while registering the winners.
+	backfillReportInterval = 100
+	// backfillPollInterval is the interval at which the processing of the
+	// registered winners is checked.
+	backfillPollInterval = 5 * time.Second
+)
+
+// Backfill registers the winners of the L1 blocks in the range [from, to] and
+// derives their settlements with the same components as the node. Blocks with
+// a registered winner are skipped. The settlements are only posted if post is
+// set, in which case the node should not be running to avoid nonce conflicts.
+// Otherwise the winners of the range are processed again by a dry run, which
+// records the settlements in shadow_settlements, where no settler reads them,
+// and leaves the processing of the winners to the node.
+// It returns once the winners of the range are processed and, if posting,
+// their settlements are posted.
+func Backfill(ctx context.Context, opts *Options, from, to int64, post bool) error {
+	if from > to {
+		return fmt.Errorf("invalid range %d to %d", from, to)
+	}
+	if post && opts.KeySigner == nil {
+		return errors.New("key signer required to post settlements")
+	}
+
+	logger := opts.Logger
+
+	st, closer, err := OpenStore(opts)
+	if err != nil {
+		return fmt.Errorf("failed initializing store: %w", err)
+	}
+	defer closer.Close()
+
+	settlementClient, err := ethclient.Dial(opts.SettlementRPCUrl)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the settlement layer: %w", err)
+	}
+
+	multiL1Client, _, err := dialL1(logger, opts)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the L1 Ethereum client: %w", err)
+	}
+
+	preconfContract, err := preconf.NewPreconfcommitmentstoreCaller(
+		opts.PreconfContractAddr,
+		settlementClient,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to instantiate preconf contract: %w", err)
+	}
+
+	oracleContract, err := rollupclient.NewOracle(opts.OracleContractAddr, settlementClient)
+	if err != nil {
+		return fmt.Errorf("failed to instantiate oracle contract: %w", err)
+	}
+
+	var slotResolver l1Listener.SlotResolver
+	if opts.BeaconUrl != "" {
+		slotResolver = l1Listener.NewBeaconSlotResolver(opts.BeaconUrl)
+	}
+
+	winnerResolver, err := newWinnerResolver(logger, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create winner resolver: %w", err)
+	}
+
+	bundleValidator, err := newBundleValidator(opts)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle validator: %w", err)
+	}
+
+	decay, err := newDecayConfig(opts)
+	if err != nil {
+		return fmt.Errorf("invalid decay config: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var owner common.Address
+	if opts.KeySigner != nil {
+		owner = opts.KeySigner.GetAddress()
+	}
+
+	callOpts := bind.CallOpts{
+		Pending: false,
+		From:    owner,
+		Context: ctx,
+	}
+
+	pc, err := updater.NewBatchPreconf(
+		logger.With("component", "preconf"),
+		&preconf.PreconfcommitmentstoreCallerSession{
+			Contract: preconfContract,
+			CallOpts: callOpts,
+		},
+		settlementClient.Client(),
+		opts.PreconfContractAddr,
+		opts.PreconfBatchSize,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create batched preconf client: %w", err)
+	}
+	oc := &rollupclient.OracleSession{Contract: oracleContract, CallOpts: callOpts}
+
+	var winnerRegister updater.WinnerRegister = st
+	builderTimeout := opts.BuilderTimeout
+	if !post {
+		if err := st.ResetDryRun(ctx, from, to); err != nil {
+			return fmt.Errorf("failed to reset dry run: %w", err)
+		}
+		winnerRegister = dryRunRegister{Store: st, from: from, to: to}
+		// Parking would exclude the blocks from the node's processing.
+		builderTimeout = 0
+	}
+
+	updtr := updater.NewUpdater(&updater.Options{
+		Logger:          logger.With("component", "updater"),
+		L1Client:        multiL1Client,
+		L2Client:        settlementClient,
+		WinnerRegister:  winnerRegister,
+		RollupClient:    oc,
+		PreconfClient:   pc,
+		Parallelism:     opts.UpdaterParallelism,
+		BuilderCacheTTL: opts.BuilderCacheTTL,
+		ReceiptPolicy:   updater.ReceiptPolicy(opts.ReceiptPolicy),
+		BundleValidator: bundleValidator,
+		Decay:           decay,
+		RetryPolicy: updater.RetryPolicy{
+			MaxAttempts: opts.BlockMaxAttempts,
+			Backoff:     opts.BlockRetryBackoff,
+			MaxBackoff:  opts.BlockRetryMaxBackoff,
+		},
+		BuilderTimeout: builderTimeout,
+	})
+	closed := []<-chan struct{}{updtr.Start(ctx)}
+
+	if post {
+		chainID, err := settlementClient.ChainID(ctx)
+		if err != nil {
+			return fmt.Errorf("failed getting chain ID: %w", err)
+		}
+
+		settlr := settler.NewSettler(
+			logger.With("component", "settler"),
+			opts.KeySigner,
+			chainID,
+			owner,
+			oracleContract,
+			st,
+			settlementClient,
+		)
+		closed = append(closed, settlr.Start(ctx))
+	}
+
+	defer func() {
+		cancel()
+		for _, c := range closed {
+			<-c
+		}
+	}()
+
+	l1Lis := l1Listener.NewL1Listener(
+		logger.With("component", "l1_listener"),
+		multiL1Client,
+		st,
+		l1Listener.Finality(opts.L1Finality),
+		nil,
+		winnerResolver,
+		slotResolver,
+	)
+
+	var registered, skipped int
+	err = l1Lis.Backfill(ctx, from, to, func(blockNum int64, skip bool) {
+		if skip {
+			skipped++
+		} else {
+			registered++
+		}
+		if (blockNum-from+1)%backfillReportInterval == 0 || blockNum == to {
+			logger.Info(
+				"backfilling winners",
+				"block", blockNum,
+				"to", to,
+				"registered", registered,
+				"skipped", skipped,
+			)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if !post {
+		return waitDryRun(ctx, logger, st, from, to)
+	}
+	return waitBackfill(ctx, logger, st, from, to)
+}
+
+// dryRunRegister processes the winners of the range of a backfill which is
+// not posting the settlements. Its state is kept apart from the one of the
+// node sharing the store, which would otherwise post the settlements or skip
+// the blocks.
+type dryRunRegister struct {
+	*store.Store
+	from, to int64
+}
+
+func (r dryRunRegister) SubscribeWinners(ctx context.Context) <-chan updater.BlockWinner {
+	return r.SubscribeDryRunWinners(ctx, r.from, r.to)
+}
+
+func (r dryRunRegister) AddSettlements(
+	ctx context.Context,
+	blockNum int64,
+	blockHash common.Hash,
+	settlements []updater.Settlement,
+) error {
+	return r.AddDryRunSettlements(ctx, blockNum, blockHash, settlements)
+}
+
+func (r dryRunRegister) QuarantineBlock(ctx context.Context, blockNum int64, _ int, lastErr string) error {
+	return r.QuarantineDryRunBlock(ctx, blockNum, lastErr)
+}
+
+// waitBackfill waits until the winners of the range are processed by the
+// updater, including the ones parked until their builder registers, and
+// their settlements are posted by the settler.
+func waitBackfill(
+	ctx context.Context,
+	logger *slog.Logger,
+	st *store.Store,
+	from, to int64,
+) error {
+	ticker := time.NewTicker(backfillPollInterval)
+	defer ticker.Stop()
+
+	var last store.RangeProgress
+	for {
+		progress, err := st.RangeProgress(ctx, from, to)
+		if err != nil {
+			return fmt.Errorf("failed to get backfill progress: %w", err)
+		}
+
+		if progress != last {
+			logger.Info(
+				"processing backfilled winners",
+				"winners", progress.Winners,
+				"unprocessed", progress.UnprocessedWinners,
+				"disputed", progress.DisputedWinners,
+				"quarantined", progress.QuarantinedWinners,
+				"parked", progress.ParkedWinners,
+				"unposted_settlements", progress.UnpostedSettlements,
+			)
+			last = progress
+		}
+
+		if progress.UnprocessedWinners == 0 && progress.ParkedWinners == 0 && progress.UnpostedSettlements == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitDryRun waits until the winners of the range are processed by the dry
+// run.
+func waitDryRun(
+	ctx context.Context,
+	logger *slog.Logger,
+	st *store.Store,
+	from, to int64,
+) error {
+	ticker := time.NewTicker(backfillPollInterval)
+	defer ticker.Stop()
+
+	var last store.DryRunProgress
+	for {
+		progress, err := st.DryRunProgress(ctx, from, to)
+		if err != nil {
+			return fmt.Errorf("failed to get dry run progress: %w", err)
+		}
+
+		if progress != last {
+			logger.Info(
+				"processing backfilled winners without posting",
+				"winners", progress.Winners,
+				"unprocessed", progress.UnprocessedWinners,
+				"disputed", progress.DisputedWinners,
+				"quarantined", progress.QuarantinedWinners,
+			)
+			last = progress
+		}
+
+		if progress.UnprocessedWinners == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -8,37 +8,72 @@ import (
 	"io"
 	"log/slog"
 	"math/big"
+	"net/url"
+	"slices"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	rollupclient "github.com/primevprotocol/contracts-abi/clients/Oracle"
 	preconf "github.com/primevprotocol/contracts-abi/clients/PreConfCommitmentStore"
 	"github.com/primevprotocol/mev-oracle/pkg/apiserver"
+	"github.com/primevprotocol/mev-oracle/pkg/indexer"
 	"github.com/primevprotocol/mev-oracle/pkg/keysigner"
+	"github.com/primevprotocol/mev-oracle/pkg/l1Client"
 	"github.com/primevprotocol/mev-oracle/pkg/l1Listener"
 	"github.com/primevprotocol/mev-oracle/pkg/settler"
 	"github.com/primevprotocol/mev-oracle/pkg/store"
 	"github.com/primevprotocol/mev-oracle/pkg/updater"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 type Options struct {
-	Logger              *slog.Logger
-	KeySigner           keysigner.KeySigner
-	HTTPPort            int
-	SettlementRPCUrl    string
-	L1RPCUrl            string
-	OracleContractAddr  common.Address
-	PreconfContractAddr common.Address
-	PgHost              string
-	PgPort              int
-	PgUser              string
-	PgPassword          string
-	PgDbname            string
-	LaggerdMode         int
-	OverrideWinners     []string
+	Logger               *slog.Logger
+	KeySigner            keysigner.KeySigner
+	HTTPPort             int
+	SettlementRPCUrl     string
+	L1RPCUrl             string
+	L1RPCUrls            []string
+	L1Quorum             int
+	BeaconUrl            string
+	OracleContractAddr   common.Address
+	PreconfContractAddr  common.Address
+	PgHost               string
+	PgPort               int
+	PgUser               string
+	PgPassword           string
+	PgDbname             string
+	LaggerdMode          int
+	L1Finality           string
+	WinnerResolver       string
+	WinnerSources        []string
+	WinnerConsensus      string
+	BuilderRegistry      string
+	RelayUrls            []string
+	RelayBuilderPubkeys  map[string]string
+	RelayPendingTimeout  time.Duration
+	OverrideWinners      []string
+	UpdaterParallelism   int
+	PreconfBatchSize     int
+	BuilderCacheTTL      time.Duration
+	ReceiptPolicy        string
+	BundlePolicy         string
+	SingleTxPolicy       string
+	DecayCurve           string
+	DecayTimestampUnit   string
+	BlockTimestampUnit   string
+	DecayPrecision       int64
+	BlockMaxAttempts     int
+	BlockRetryBackoff    time.Duration
+	BlockRetryMaxBackoff time.Duration
+	BuilderTimeout       time.Duration
+	CommitmentIndexer    bool
+	IndexerStartBlock    uint64
+	IndexerCheckInterval int
+	// Shadow records the settlements instead of posting them, so that the
+	// oracle can run next to another one without a key.
+	Shadow bool
 }
 
 type Node struct {
@@ -63,7 +98,10 @@ func NewNode(opts *Options) (*Node, error) {
 		return nil, err
 	}
 
-	owner := opts.KeySigner.GetAddress()
+	var owner common.Address
+	if opts.KeySigner != nil {
+		owner = opts.KeySigner.GetAddress()
+	}
 
 	settlementClient, err := ethclient.Dial(opts.SettlementRPCUrl)
 	if err != nil {
@@ -77,7 +115,7 @@ func NewNode(opts *Options) (*Node, error) {
 		return nil, err
 	}
 
-	l1Client, err := ethclient.Dial(opts.L1RPCUrl)
+	multiL1Client, headSubscriber, err := dialL1(nd.logger, opts)
 	if err != nil {
 		nd.logger.Error("Failed to connect to the L1 Ethereum client", "error", err)
 		return nil, err
@@ -93,7 +131,7 @@ func NewNode(opts *Options) (*Node, error) {
 
 	var listenerL1Client l1Listener.EthClient
 
-	listenerL1Client = l1Client
+	listenerL1Client = multiL1Client
 	if opts.LaggerdMode > 0 {
 		listenerL1Client = &laggerdL1Client{EthClient: listenerL1Client, amount: opts.LaggerdMode}
 	}
@@ -115,8 +153,35 @@ func NewNode(opts *Options) (*Node, error) {
 		return nil, err
 	}
 
-	if opts.OverrideWinners != nil && len(opts.OverrideWinners) > 0 {
-		listenerL1Client = &winnerOverrideL1Client{EthClient: listenerL1Client, winners: opts.OverrideWinners}
+	var slotResolver l1Listener.SlotResolver
+	if opts.BeaconUrl != "" {
+		slotResolver = l1Listener.NewBeaconSlotResolver(opts.BeaconUrl)
+	}
+
+	winnerResolver, err := newWinnerResolver(nd.logger, opts)
+	if err != nil {
+		nd.logger.Error("failed to create winner resolver", "error", err)
+		cancel()
+		return nil, err
+	}
+
+	bundleValidator, err := newBundleValidator(opts)
+	if err != nil {
+		nd.logger.Error("failed to create bundle validator", "error", err)
+		cancel()
+		return nil, err
+	}
+
+	decay, err := newDecayConfig(opts)
+	if err != nil {
+		nd.logger.Error("invalid decay config", "error", err)
+		cancel()
+		return nil, err
+	}
+
+	if opts.Shadow && len(opts.OverrideWinners) > 0 {
+		nd.logger.Warn("override winners are not set in shadow mode", "winners", opts.OverrideWinners)
+	} else if len(opts.OverrideWinners) > 0 {
 		for _, winner := range opts.OverrideWinners {
 			err := setBuilderMapping(
 				ctx,
@@ -135,7 +200,15 @@ func NewNode(opts *Options) (*Node, error) {
 		}
 	}
 
-	l1Lis := l1Listener.NewL1Listener(nd.logger.With("component", "l1_listener"), listenerL1Client, st)
+	l1Lis := l1Listener.NewL1Listener(
+		nd.logger.With("component", "l1_listener"),
+		listenerL1Client,
+		st,
+		l1Listener.Finality(opts.L1Finality),
+		headSubscriber,
+		winnerResolver,
+		slotResolver,
+	)
 	l1LisClosed := l1Lis.Start(ctx)
 
 	callOpts := bind.CallOpts{
@@ -144,30 +217,103 @@ func NewNode(opts *Options) (*Node, error) {
 		Context: ctx,
 	}
 
-	pc := &preconf.PreconfcommitmentstoreCallerSession{
-		Contract: preconfContract,
-		CallOpts: callOpts,
+	pc, err := updater.NewBatchPreconf(
+		nd.logger.With("component", "preconf"),
+		&preconf.PreconfcommitmentstoreCallerSession{
+			Contract: preconfContract,
+			CallOpts: callOpts,
+		},
+		settlementClient.Client(),
+		opts.PreconfContractAddr,
+		opts.PreconfBatchSize,
+	)
+	if err != nil {
+		nd.logger.Error("failed to create batched preconf client", "error", err)
+		cancel()
+		return nil, err
 	}
 	oc := &rollupclient.OracleSession{Contract: oracleContract, CallOpts: callOpts}
 
-	updtr := updater.NewUpdater(nd.logger.With("component", "updater"), l1Client, l2Client, st, oc, pc)
+	// The commitments of the blocks are read from the local index if the
+	// indexer is enabled.
+	var (
+		preconfClient  updater.Preconf = pc
+		indexerClosed  <-chan struct{}
+		indexerMetrics []prometheus.Collector
+	)
+	if opts.CommitmentIndexer {
+		idxr, err := indexer.NewIndexer(
+			nd.logger.With("component", "indexer"),
+			settlementClient,
+			st,
+			pc,
+			opts.PreconfContractAddr,
+			opts.IndexerStartBlock,
+		)
+		if err != nil {
+			nd.logger.Error("failed to create commitment indexer", "error", err)
+			cancel()
+			return nil, err
+		}
+		indexerClosed = idxr.Start(ctx)
+		indexerMetrics = idxr.Metrics()
+		preconfClient = indexer.NewIndexedPreconf(
+			nd.logger.With("component", "indexed_preconf"),
+			pc,
+			idxr,
+			opts.IndexerCheckInterval,
+		)
+	}
+
+	updtr := updater.NewUpdater(&updater.Options{
+		Logger:          nd.logger.With("component", "updater"),
+		L1Client:        multiL1Client,
+		L2Client:        l2Client,
+		WinnerRegister:  st,
+		RollupClient:    oc,
+		PreconfClient:   preconfClient,
+		Parallelism:     opts.UpdaterParallelism,
+		BuilderCacheTTL: opts.BuilderCacheTTL,
+		ReceiptPolicy:   updater.ReceiptPolicy(opts.ReceiptPolicy),
+		BundleValidator: bundleValidator,
+		Decay:           decay,
+		RetryPolicy: updater.RetryPolicy{
+			MaxAttempts: opts.BlockMaxAttempts,
+			Backoff:     opts.BlockRetryBackoff,
+			MaxBackoff:  opts.BlockRetryMaxBackoff,
+		},
+		BuilderTimeout: opts.BuilderTimeout,
+	})
 	updtrClosed := updtr.Start(ctx)
 
-	settlr := settler.NewSettler(
-		nd.logger.With("component", "settler"),
-		opts.KeySigner,
-		chainID,
-		owner,
-		oracleContract,
-		st,
-		settlementClient,
+	var (
+		settlrClosed  <-chan struct{}
+		settlrMetrics []prometheus.Collector
 	)
-	settlrClosed := settlr.Start(ctx)
+	if opts.Shadow {
+		recorder := settler.NewRecorder(nd.logger.With("component", "recorder"), st)
+		settlrClosed = recorder.Start(ctx)
+		settlrMetrics = recorder.Metrics()
+	} else {
+		settlr := settler.NewSettler(
+			nd.logger.With("component", "settler"),
+			opts.KeySigner,
+			chainID,
+			owner,
+			oracleContract,
+			st,
+			settlementClient,
+		)
+		settlrClosed = settlr.Start(ctx)
+		settlrMetrics = settlr.Metrics()
+	}
 
 	srv := apiserver.New(nd.logger.With("component", "apiserver"), st)
+	srv.RegisterMetricsCollectors(multiL1Client.Metrics()...)
 	srv.RegisterMetricsCollectors(l1Lis.Metrics()...)
 	srv.RegisterMetricsCollectors(updtr.Metrics()...)
-	srv.RegisterMetricsCollectors(settlr.Metrics()...)
+	srv.RegisterMetricsCollectors(settlrMetrics...)
+	srv.RegisterMetricsCollectors(indexerMetrics...)
 
 	srvClosed := srv.Start(fmt.Sprintf(":%d", opts.HTTPPort))
 
@@ -183,6 +329,9 @@ func NewNode(opts *Options) (*Node, error) {
 			<-l1LisClosed
 			<-updtrClosed
 			<-settlrClosed
+			if indexerClosed != nil {
+				<-indexerClosed
+			}
 			<-srvClosed
 		}()
 
@@ -219,6 +368,23 @@ func (n *Node) Close() (err error) {
 	}
 }
 
+// OpenStore connects to the database and returns the store of the oracle
+// along with the closer of the connection. It is used by the commands which
+// operate on the oracle state without starting the node.
+func OpenStore(opts *Options) (*store.Store, io.Closer, error) {
+	db, err := initDB(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	st, err := store.NewStore(db)
+	if err != nil {
+		_ = db.Close()
+		return nil, nil, err
+	}
+	return st, db, nil
+}
+
 func initDB(opts *Options) (db *sql.DB, err error) {
 	// Connection string
 	psqlInfo := fmt.Sprintf(
@@ -241,35 +407,154 @@ func initDB(opts *Options) (db *sql.DB, err error) {
 	return db, err
 }
 
-type laggerdL1Client struct {
-	l1Listener.EthClient
-	amount int
+// dialL1 connects to the L1 endpoints. The first websocket or IPC endpoint is
+// returned as the subscriber for new heads, if any.
+func dialL1(logger *slog.Logger, opts *Options) (*l1Client.MultiClient, l1Listener.HeadSubscriber, error) {
+	var (
+		endpoints []l1Client.Endpoint
+		// New heads can only be pushed over websocket or IPC connections.
+		headSubscriber l1Listener.HeadSubscriber
+	)
+	for _, rpcUrl := range append([]string{opts.L1RPCUrl}, opts.L1RPCUrls...) {
+		client, err := ethclient.Dial(rpcUrl)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// The endpoints are named by host as the URLs might contain API keys.
+		name := fmt.Sprintf("l1-%d", len(endpoints))
+		if u, err := url.Parse(rpcUrl); err == nil {
+			if u.Host != "" {
+				name = fmt.Sprintf("%s-%d", u.Host, len(endpoints))
+			}
+			if headSubscriber == nil && u.Scheme != "http" && u.Scheme != "https" {
+				headSubscriber = client
+			}
+		}
+		endpoints = append(endpoints, l1Client.Endpoint{Name: name, Client: client})
+	}
+
+	multiClient, err := l1Client.NewMultiClient(
+		logger.With("component", "l1_client"),
+		endpoints,
+		max(opts.L1Quorum, 1),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return multiClient, headSubscriber, nil
 }
 
-func (l *laggerdL1Client) BlockNumber(ctx context.Context) (uint64, error) {
-	blkNum, err := l.EthClient.BlockNumber(ctx)
+// newBundleValidator creates the validator of the bundle policy. The
+// commitments to a single transaction are validated with the single
+// transaction policy if it is set.
+func newBundleValidator(opts *Options) (updater.BundleValidator, error) {
+	bundle, err := updater.NewBundleValidator(opts.BundlePolicy)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	if opts.SingleTxPolicy == "" {
+		return bundle, nil
+	}
+	single, err := updater.NewBundleValidator(opts.SingleTxPolicy)
+	if err != nil {
+		return nil, err
 	}
+	return updater.SizedBundleValidator{Single: single, Bundle: bundle}, nil
+}
 
-	return blkNum - uint64(l.amount), nil
+// newDecayConfig creates the decay config of the options.
+func newDecayConfig(opts *Options) (updater.DecayConfig, error) {
+	decay := updater.DecayConfig{
+		Curve:          updater.DecayCurve(opts.DecayCurve),
+		CommitmentUnit: updater.TimestampUnit(opts.DecayTimestampUnit),
+		BlockTimeUnit:  updater.TimestampUnit(opts.BlockTimestampUnit),
+		Precision:      opts.DecayPrecision,
+	}
+	return decay, decay.Validate()
+}
+
+// newWinnerResolver creates the resolver determining the winners of the L1
+// blocks as configured by the options.
+func newWinnerResolver(logger *slog.Logger, opts *Options) (l1Listener.WinnerResolver, error) {
+	if len(opts.OverrideWinners) > 0 {
+		return l1Listener.NewOverrideResolver(opts.OverrideWinners), nil
+	}
+
+	var (
+		registry *l1Listener.BuilderRegistry
+		err      error
+	)
+	if opts.BuilderRegistry != "" {
+		registry, err = l1Listener.LoadBuilderRegistry(opts.BuilderRegistry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load builder registry: %w", err)
+		}
+	}
+
+	if len(opts.WinnerSources) == 0 {
+		return newSourceResolver(logger, opts.WinnerResolver, registry, opts)
+	}
+
+	// The fee recipient is only a builder name if the registry maps it to
+	// one, otherwise it never agrees with the other sources.
+	if len(opts.WinnerSources) > 1 &&
+		slices.Contains(opts.WinnerSources, "fee-recipient") &&
+		!registry.HasFeeRecipients() {
+		return nil, errors.New("fee-recipient winner source combined without fee recipients in the builder registry")
+	}
+
+	sources := make([]l1Listener.NamedResolver, 0, len(opts.WinnerSources))
+	for _, name := range opts.WinnerSources {
+		resolver, err := newSourceResolver(logger, name, registry, opts)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, l1Listener.NamedResolver{Name: name, Resolver: resolver})
+	}
+	return l1Listener.NewConsensusResolver(
+		l1Listener.ConsensusPolicy(opts.WinnerConsensus),
+		sources,
+	), nil
 }
 
-type winnerOverrideL1Client struct {
+func newSourceResolver(
+	logger *slog.Logger,
+	name string,
+	registry *l1Listener.BuilderRegistry,
+	opts *Options,
+) (l1Listener.WinnerResolver, error) {
+	switch name {
+	case "fee-recipient":
+		return l1Listener.NewFeeRecipientResolver(registry), nil
+	case "relay":
+		resolver, err := l1Listener.NewRelayResolver(
+			logger.With("component", "relay_resolver"),
+			opts.RelayUrls,
+			opts.RelayBuilderPubkeys,
+			opts.RelayPendingTimeout,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create relay winner resolver: %w", err)
+		}
+		return resolver, nil
+	default:
+		return l1Listener.NewExtraDataResolver(registry), nil
+	}
+}
+
+type laggerdL1Client struct {
 	l1Listener.EthClient
-	winners []string
+	amount int
 }
 
-func (w *winnerOverrideL1Client) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
-	hdr, err := w.EthClient.HeaderByNumber(ctx, number)
+func (l *laggerdL1Client) BlockNumber(ctx context.Context) (uint64, error) {
+	blkNum, err := l.EthClient.BlockNumber(ctx)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	idx := number.Int64() % int64(len(w.winners))
-	hdr.Extra = []byte(w.winners[idx])
-
-	return hdr, nil
+	return blkNum - uint64(l.amount), nil
 }
 
 func setBuilderMapping(
@@ -0,0 +1,106 @@
+package node
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/ethclient"
+	rollupclient "github.com/primevprotocol/contracts-abi/clients/Oracle"
+	"github.com/primevprotocol/mev-oracle/pkg/settler"
+	"github.com/primevprotocol/mev-oracle/pkg/store"
+)
+
+// ShadowDiff compares the settlements recorded in shadow mode for the L1
+// blocks from to to with those posted by the oracle whose database is given
+// by the reference connection string.
+func ShadowDiff(ctx context.Context, opts *Options, from, to int64, reference string) (settler.DiffReport, error) {
+	shadow, err := shadowDecisions(ctx, opts, from, to)
+	if err != nil {
+		return settler.DiffReport{}, err
+	}
+
+	db, err := sql.Open("postgres", reference)
+	if err != nil {
+		return settler.DiffReport{}, err
+	}
+	defer db.Close()
+
+	posted, err := store.NewReader(db).PostedDecisions(ctx, from, to)
+	if err != nil {
+		return settler.DiffReport{}, fmt.Errorf("failed to get reference settlements: %w", err)
+	}
+	return settler.Diff(shadow, posted), nil
+}
+
+// ShadowChainDiff compares the settlements recorded in shadow mode for the
+// L1 blocks from to to with the commitments processed by the oracle contract
+// in the settlement chain blocks chainFrom to chainTo, as posted by the
+// transactions which emitted the events. The settlement chain blocks may also
+// hold the commitments of L1 blocks out of the range, which are left out. The
+// returns are not compared as the contract emits no event for them.
+func ShadowChainDiff(
+	ctx context.Context,
+	opts *Options,
+	from, to int64,
+	chainFrom, chainTo uint64,
+) (settler.DiffReport, error) {
+	decisions, err := shadowDecisions(ctx, opts, from, to)
+	if err != nil {
+		return settler.DiffReport{}, err
+	}
+	shadow := decisions[:0]
+	for _, d := range decisions {
+		if d.Type != settler.SettlementTypeReturn {
+			shadow = append(shadow, d)
+		}
+	}
+
+	client, err := ethclient.DialContext(ctx, opts.SettlementRPCUrl)
+	if err != nil {
+		return settler.DiffReport{}, fmt.Errorf("failed to connect to the settlement layer: %w", err)
+	}
+	defer client.Close()
+
+	oracle, err := rollupclient.NewOracleFilterer(opts.OracleContractAddr, client)
+	if err != nil {
+		return settler.DiffReport{}, err
+	}
+	it, err := oracle.FilterCommitmentProcessed(&bind.FilterOpts{
+		Start:   chainFrom,
+		End:     &chainTo,
+		Context: ctx,
+	})
+	if err != nil {
+		return settler.DiffReport{}, fmt.Errorf("failed to filter processed commitments: %w", err)
+	}
+	defer it.Close()
+
+	var processed []settler.Decision
+	for it.Next() {
+		d, err := settler.ProcessedDecision(ctx, client, it.Event)
+		if err != nil {
+			return settler.DiffReport{}, err
+		}
+		processed = append(processed, d)
+	}
+	if err := it.Error(); err != nil {
+		return settler.DiffReport{}, fmt.Errorf("failed to filter processed commitments: %w", err)
+	}
+	return settler.Diff(shadow, settler.InRange(processed, from, to)), nil
+}
+
+func shadowDecisions(ctx context.Context, opts *Options, from, to int64) ([]settler.Decision, error) {
+	st, closer, err := OpenStore(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store: %w", err)
+	}
+	defer closer.Close()
+
+	decisions, err := st.ShadowDecisions(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shadow settlements: %w", err)
+	}
+	return decisions, nil
+}
@@ -0,0 +1,199 @@
+package settler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	rollupclient "github.com/primevprotocol/contracts-abi/clients/Oracle"
+)
+
+// Decision is the settlement of a commitment as posted, or as it would have
+// been posted, to the oracle contract. The block number and decay are not
+// always known, in which case they are zero and nil.
+type Decision struct {
+	CommitmentIdx   string         `json:"commitmentIndex"`
+	BlockNum        int64          `json:"blockNumber,omitempty"`
+	Type            SettlementType `json:"type"`
+	DecayPercentage *int64         `json:"decayPercentage,omitempty"`
+}
+
+// Mismatch is a commitment settled differently by the shadow and the
+// reference.
+type Mismatch struct {
+	Shadow    Decision `json:"shadow"`
+	Reference Decision `json:"reference"`
+}
+
+// DiffReport compares the decisions of a shadow oracle with the reference
+// ones, each list ordered by block number and commitment index.
+type DiffReport struct {
+	Matched       int        `json:"matched"`
+	Mismatched    []Mismatch `json:"mismatched"`
+	OnlyShadow    []Decision `json:"onlyShadow"`
+	OnlyReference []Decision `json:"onlyReference"`
+}
+
+// Diff compares the decisions by commitment index. The block number and
+// decay are only compared if known on both sides.
+func Diff(shadow, reference []Decision) DiffReport {
+	report := DiffReport{
+		Mismatched:    []Mismatch{},
+		OnlyShadow:    []Decision{},
+		OnlyReference: []Decision{},
+	}
+
+	shadowByIdx := make(map[string]Decision, len(shadow))
+	for _, d := range shadow {
+		shadowByIdx[d.CommitmentIdx] = d
+	}
+	referenceByIdx := make(map[string]Decision, len(reference))
+	for _, d := range reference {
+		referenceByIdx[d.CommitmentIdx] = d
+	}
+
+	for idx, s := range shadowByIdx {
+		r, found := referenceByIdx[idx]
+		switch {
+		case !found:
+			report.OnlyShadow = append(report.OnlyShadow, s)
+		case s.matches(r):
+			report.Matched++
+		default:
+			report.Mismatched = append(report.Mismatched, Mismatch{Shadow: s, Reference: r})
+		}
+	}
+	for idx, r := range referenceByIdx {
+		if _, found := shadowByIdx[idx]; !found {
+			report.OnlyReference = append(report.OnlyReference, r)
+		}
+	}
+
+	sortDecisions(report.OnlyShadow)
+	sortDecisions(report.OnlyReference)
+	sort.Slice(report.Mismatched, func(i, j int) bool {
+		return report.Mismatched[i].Shadow.less(report.Mismatched[j].Shadow)
+	})
+	return report
+}
+
+// InRange returns the decisions of the L1 blocks from to to, in place. The
+// decisions without block number are dropped.
+func InRange(decisions []Decision, from, to int64) []Decision {
+	inRange := decisions[:0]
+	for _, d := range decisions {
+		if d.BlockNum < from || d.BlockNum > to {
+			continue
+		}
+		inRange = append(inRange, d)
+	}
+	return inRange
+}
+
+func (d Decision) matches(o Decision) bool {
+	if d.Type != o.Type {
+		return false
+	}
+	if d.BlockNum != 0 && o.BlockNum != 0 && d.BlockNum != o.BlockNum {
+		return false
+	}
+	if d.DecayPercentage != nil && o.DecayPercentage != nil && *d.DecayPercentage != *o.DecayPercentage {
+		return false
+	}
+	return true
+}
+
+func (d Decision) less(o Decision) bool {
+	if d.BlockNum != o.BlockNum {
+		return d.BlockNum < o.BlockNum
+	}
+	return d.CommitmentIdx < o.CommitmentIdx
+}
+
+func sortDecisions(decisions []Decision) {
+	sort.Slice(decisions, func(i, j int) bool {
+		return decisions[i].less(decisions[j])
+	})
+}
+
+// TransactionReader returns the transactions of the settlement chain.
+type TransactionReader interface {
+	TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
+}
+
+// ProcessedDecision returns the settlement posted by the transaction which
+// emitted the CommitmentProcessed event. The event only names the commitment
+// by its hash, while the settlements are recorded by the commitment index
+// passed to processBuilderCommitmentForBlockNumber, so the decision is decoded
+// from that call to compare the same keys.
+func ProcessedDecision(
+	ctx context.Context,
+	txs TransactionReader,
+	event *rollupclient.OracleCommitmentProcessed,
+) (Decision, error) {
+	tx, _, err := txs.TransactionByHash(ctx, event.Raw.TxHash)
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to get transaction %s: %w", event.Raw.TxHash, err)
+	}
+
+	if tx.To() == nil || *tx.To() != event.Raw.Address {
+		return Decision{}, fmt.Errorf("transaction %s not sent to the oracle contract", event.Raw.TxHash)
+	}
+
+	d, err := decodeDecision(tx.Data())
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to decode transaction %s: %w", event.Raw.TxHash, err)
+	}
+	if (d.Type == SettlementTypeSlash) != event.IsSlash {
+		return Decision{}, fmt.Errorf("transaction %s does not match its event", event.Raw.TxHash)
+	}
+	return d, nil
+}
+
+func decodeDecision(data []byte) (Decision, error) {
+	oracleABI, err := rollupclient.OracleMetaData.GetAbi()
+	if err != nil {
+		return Decision{}, err
+	}
+	if len(data) < 4 {
+		return Decision{}, errors.New("no method called")
+	}
+	method, err := oracleABI.MethodById(data[:4])
+	if err != nil {
+		return Decision{}, err
+	}
+	if method.Name != "processBuilderCommitmentForBlockNumber" {
+		return Decision{}, fmt.Errorf("unexpected method %s", method.Name)
+	}
+
+	var args struct {
+		CommitmentIndex              [32]byte
+		BlockNumber                  *big.Int
+		BlockBuilderName             string
+		IsSlash                      bool
+		ResidualBidPercentAfterDecay *big.Int
+	}
+	values, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return Decision{}, err
+	}
+	if err := method.Inputs.Copy(&args, values); err != nil {
+		return Decision{}, err
+	}
+
+	decay := args.ResidualBidPercentAfterDecay.Int64()
+	d := Decision{
+		CommitmentIdx:   common.Bytes2Hex(args.CommitmentIndex[:]),
+		BlockNum:        args.BlockNumber.Int64(),
+		Type:            SettlementTypeReward,
+		DecayPercentage: &decay,
+	}
+	if args.IsSlash {
+		d.Type = SettlementTypeSlash
+	}
+	return d, nil
+}
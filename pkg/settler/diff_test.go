@@ -0,0 +1,208 @@
+package settler_test
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	rollupclient "github.com/primevprotocol/contracts-abi/clients/Oracle"
+	"github.com/primevprotocol/mev-oracle/pkg/settler"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	decay := func(d int64) *int64 { return &d }
+
+	shadow := []settler.Decision{
+		{CommitmentIdx: "01", BlockNum: 10, Type: settler.SettlementTypeReward, DecayPercentage: decay(10)},
+		{CommitmentIdx: "02", BlockNum: 10, Type: settler.SettlementTypeSlash, DecayPercentage: decay(20)},
+		{CommitmentIdx: "03", BlockNum: 11, Type: settler.SettlementTypeReward, DecayPercentage: decay(30)},
+		{CommitmentIdx: "04", BlockNum: 12, Type: settler.SettlementTypeReward, DecayPercentage: decay(40)},
+		{CommitmentIdx: "05", BlockNum: 12, Type: settler.SettlementTypeReturn, DecayPercentage: decay(0)},
+	}
+
+	t.Run("database", func(t *testing.T) {
+		t.Parallel()
+
+		reference := []settler.Decision{
+			{CommitmentIdx: "01", BlockNum: 10, Type: settler.SettlementTypeReward, DecayPercentage: decay(10)},
+			{CommitmentIdx: "02", BlockNum: 10, Type: settler.SettlementTypeReward, DecayPercentage: decay(20)},
+			{CommitmentIdx: "03", BlockNum: 11, Type: settler.SettlementTypeReward, DecayPercentage: decay(31)},
+			{CommitmentIdx: "05", BlockNum: 12, Type: settler.SettlementTypeReturn, DecayPercentage: decay(0)},
+			{CommitmentIdx: "06", BlockNum: 13, Type: settler.SettlementTypeSlash, DecayPercentage: decay(50)},
+		}
+
+		report := settler.Diff(shadow, reference)
+		if report.Matched != 2 {
+			t.Errorf("expected 2 matches, got %d", report.Matched)
+		}
+
+		want := []settler.Mismatch{
+			{Shadow: shadow[1], Reference: reference[1]},
+			{Shadow: shadow[2], Reference: reference[2]},
+		}
+		if !reflect.DeepEqual(report.Mismatched, want) {
+			t.Errorf("expected mismatches %v, got %v", want, report.Mismatched)
+		}
+		if !reflect.DeepEqual(report.OnlyShadow, []settler.Decision{shadow[3]}) {
+			t.Errorf("unexpected shadow only decisions %v", report.OnlyShadow)
+		}
+		if !reflect.DeepEqual(report.OnlyReference, []settler.Decision{reference[4]}) {
+			t.Errorf("unexpected reference only decisions %v", report.OnlyReference)
+		}
+	})
+
+	t.Run("chain", func(t *testing.T) {
+		t.Parallel()
+
+		// The decisions without block number and decay match on their type.
+		processed := []settler.Decision{
+			{CommitmentIdx: "01", Type: settler.SettlementTypeReward},
+			{CommitmentIdx: "02", Type: settler.SettlementTypeSlash},
+			{CommitmentIdx: "03", Type: settler.SettlementTypeSlash},
+		}
+
+		report := settler.Diff(shadow[:4], processed)
+		if report.Matched != 2 {
+			t.Errorf("expected 2 matches, got %d", report.Matched)
+		}
+		if len(report.Mismatched) != 1 || report.Mismatched[0].Shadow.CommitmentIdx != "03" {
+			t.Errorf("unexpected mismatches %v", report.Mismatched)
+		}
+		if len(report.OnlyShadow) != 1 || len(report.OnlyReference) != 0 {
+			t.Errorf("unexpected unmatched decisions %v %v", report.OnlyShadow, report.OnlyReference)
+		}
+	})
+
+	t.Run("chain window", func(t *testing.T) {
+		t.Parallel()
+
+		// The settlement chain blocks also processed the commitments of the
+		// L1 blocks before and after the compared range.
+		processed := []settler.Decision{
+			{CommitmentIdx: "00", BlockNum: 9, Type: settler.SettlementTypeReward, DecayPercentage: decay(5)},
+			{CommitmentIdx: "01", BlockNum: 10, Type: settler.SettlementTypeReward, DecayPercentage: decay(10)},
+			{CommitmentIdx: "02", BlockNum: 10, Type: settler.SettlementTypeSlash, DecayPercentage: decay(20)},
+			{CommitmentIdx: "03", BlockNum: 11, Type: settler.SettlementTypeReward, DecayPercentage: decay(30)},
+			{CommitmentIdx: "06", BlockNum: 13, Type: settler.SettlementTypeSlash, DecayPercentage: decay(50)},
+		}
+
+		report := settler.Diff(shadow[:3], settler.InRange(processed, 10, 11))
+		if report.Matched != 3 {
+			t.Errorf("expected 3 matches, got %d", report.Matched)
+		}
+		if len(report.Mismatched) != 0 || len(report.OnlyShadow) != 0 || len(report.OnlyReference) != 0 {
+			t.Errorf("unexpected differences %v", report)
+		}
+	})
+}
+
+type testTxReader map[common.Hash]*types.Transaction
+
+func (r testTxReader) TransactionByHash(
+	_ context.Context,
+	hash common.Hash,
+) (*types.Transaction, bool, error) {
+	tx, found := r[hash]
+	if !found {
+		return nil, false, ethereum.NotFound
+	}
+	return tx, false, nil
+}
+
+func TestProcessedDecision(t *testing.T) {
+	t.Parallel()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oracleAddr := common.HexToAddress("0x0a")
+	oracleABI, err := rollupclient.OracleMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transactor, err := rollupclient.NewOracleTransactor(oracleAddr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filterer, err := rollupclient.NewOracleFilterer(oracleAddr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer := types.HomesteadSigner{}
+	opts := &bind.TransactOpts{
+		From:     crypto.PubkeyToAddress(key.PublicKey),
+		Nonce:    big.NewInt(1),
+		GasPrice: big.NewInt(1),
+		GasLimit: 100000,
+		NoSend:   true,
+		Signer: func(_ common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			return types.SignTx(tx, signer, key)
+		},
+	}
+
+	// The settler posts the commitment by its index while the event names it
+	// by the commitment hash.
+	commitmentIdx := common.HexToHash("0x01")
+	commitmentHash := common.HexToHash("0xff01")
+
+	tx, err := transactor.ProcessBuilderCommitmentForBlockNumber(
+		opts,
+		commitmentIdx,
+		big.NewInt(10),
+		"builder",
+		true,
+		big.NewInt(20),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := oracleABI.Events["CommitmentProcessed"]
+	data, err := event.Inputs.Pack(commitmentHash, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	processed, err := filterer.ParseCommitmentProcessed(types.Log{
+		Address: oracleAddr,
+		Topics:  []common.Hash{event.ID},
+		Data:    data,
+		TxHash:  tx.Hash(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := settler.ProcessedDecision(context.Background(), testTxReader{tx.Hash(): tx}, processed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decay := int64(20)
+	shadow := []settler.Decision{{
+		CommitmentIdx:   common.Bytes2Hex(commitmentIdx[:]),
+		BlockNum:        10,
+		Type:            settler.SettlementTypeSlash,
+		DecayPercentage: &decay,
+	}}
+	report := settler.Diff(shadow, []settler.Decision{d})
+	if report.Matched != 1 {
+		t.Fatalf("expected the recorded settlement to match the posted one, got %+v", report)
+	}
+
+	processed.IsSlash = false
+	_, err = settler.ProcessedDecision(context.Background(), testTxReader{tx.Hash(): tx}, processed)
+	if err == nil {
+		t.Fatal("expected error for an event not matching its transaction")
+	}
+}
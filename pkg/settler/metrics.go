@@ -14,6 +14,7 @@ type metrics struct {
 	CurrentSettlementL1Block  prometheus.Gauge
 	SettlementsPostedCount    prometheus.Counter
 	SettlementsConfirmedCount prometheus.Counter
+	SettlementsRecordedCount  prometheus.Counter
 }
 
 func newMetrics() *metrics {
@@ -66,6 +67,14 @@ func newMetrics() *metrics {
 			Help:      "Number of settlement transactions confirmed",
 		},
 	)
+	m.SettlementsRecordedCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "settlements_recorded_count",
+			Help:      "Number of settlements recorded without posting in shadow mode",
+		},
+	)
 	return m
 }
 
@@ -77,5 +86,6 @@ func (m *metrics) Collectors() []prometheus.Collector {
 		m.CurrentSettlementL1Block,
 		m.SettlementsPostedCount,
 		m.SettlementsConfirmedCount,
+		m.SettlementsRecordedCount,
 	}
 }
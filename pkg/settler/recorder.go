@@ -0,0 +1,143 @@
+package settler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/errgroup"
+)
+
+type RecorderRegister interface {
+	SubscribeSettlements(ctx context.Context) <-chan Settlement
+	SubscribeReturns(ctx context.Context, limit int) <-chan Return
+	// RecordShadowSettlements records the settlements of the bids as they
+	// would have been posted to the oracle contract and marks them settled.
+	RecordShadowSettlements(ctx context.Context, bidIDs [][]byte) error
+}
+
+// Recorder replaces the settler in shadow mode. It consumes the settlements
+// and returns like the settler but records them instead of posting them, so
+// that its decisions can be compared to those of another oracle.
+type Recorder struct {
+	logger   *slog.Logger
+	register RecorderRegister
+	metrics  *metrics
+}
+
+func NewRecorder(logger *slog.Logger, register RecorderRegister) *Recorder {
+	return &Recorder{
+		logger:   logger,
+		register: register,
+		metrics:  newMetrics(),
+	}
+}
+
+func (r *Recorder) Metrics() []prometheus.Collector {
+	return []prometheus.Collector{
+		r.metrics.CurrentSettlementL1Block,
+		r.metrics.SettlementsRecordedCount,
+	}
+}
+
+func (r *Recorder) settlementRecorder(ctx context.Context) error {
+RESTART:
+	cctx, unsub := context.WithCancel(ctx)
+	settlementChan := r.register.SubscribeSettlements(cctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			unsub()
+			return ctx.Err()
+		case settlement, more := <-settlementChan:
+			if !more {
+				unsub()
+				goto RESTART
+			}
+
+			err := r.register.RecordShadowSettlements(ctx, [][]byte{settlement.BidID})
+			if err != nil {
+				r.logger.Error("failed to record settlement", "error", err)
+				unsub()
+				time.Sleep(5 * time.Second)
+				goto RESTART
+			}
+
+			r.metrics.SettlementsRecordedCount.Inc()
+			r.metrics.CurrentSettlementL1Block.Set(float64(settlement.BlockNum))
+
+			r.logger.Info(
+				"builder commitment recorded",
+				"blockNum", settlement.BlockNum,
+				"commitmentIdx", fmt.Sprintf("%x", settlement.CommitmentIdx),
+				"builder", settlement.Builder,
+				"settlementType", string(settlement.Type),
+				"decayPercentage", settlement.DecayPercentage,
+			)
+		}
+	}
+}
+
+func (r *Recorder) returnRecorder(ctx context.Context) error {
+RESTART:
+	cctx, unsub := context.WithCancel(ctx)
+	returnsChan := r.register.SubscribeReturns(cctx, batchSize)
+
+	for {
+		select {
+		case <-ctx.Done():
+			unsub()
+			return ctx.Err()
+		case returns, more := <-returnsChan:
+			if !more {
+				unsub()
+				goto RESTART
+			}
+
+			bidIDs := make([][]byte, 0, len(returns.BidIDs))
+			for _, bidID := range returns.BidIDs {
+				b := make([]byte, 32)
+				copy(b, bidID[:])
+				bidIDs = append(bidIDs, b)
+			}
+
+			err := r.register.RecordShadowSettlements(ctx, bidIDs)
+			if err != nil {
+				r.logger.Error("failed to record return", "error", err)
+				unsub()
+				time.Sleep(5 * time.Second)
+				goto RESTART
+			}
+
+			r.metrics.SettlementsRecordedCount.Add(float64(len(bidIDs)))
+
+			r.logger.Info("builder return recorded", "bidIDs", returns, "batchSize", len(bidIDs))
+		}
+	}
+}
+
+func (r *Recorder) Start(ctx context.Context) <-chan struct{} {
+	doneChan := make(chan struct{})
+
+	eg, egCtx := errgroup.WithContext(ctx)
+
+	eg.Go(func() error {
+		return r.settlementRecorder(egCtx)
+	})
+
+	eg.Go(func() error {
+		return r.returnRecorder(egCtx)
+	})
+
+	go func() {
+		defer close(doneChan)
+		if err := eg.Wait(); err != nil {
+			r.logger.Error("recorder error", "error", err)
+		}
+	}()
+
+	return doneChan
+}
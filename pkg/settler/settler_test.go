@@ -28,6 +28,7 @@ type testRegister struct {
 	mu                   sync.Mutex
 	settlementsInitiated [][]byte
 	settlementsCompleted atomic.Int32
+	recorded             [][]byte
 }
 
 func (t *testRegister) LastNonce() (int64, error) {
@@ -83,6 +84,21 @@ func (t *testRegister) MarkSettlementComplete(ctx context.Context, nonce uint64)
 	return 1, nil
 }
 
+func (t *testRegister) RecordShadowSettlements(ctx context.Context, bidIDs [][]byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.recorded = append(t.recorded, bidIDs...)
+	return nil
+}
+
+func (t *testRegister) recordedCount() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.recorded)
+}
+
 func (t *testRegister) settlementsInitiatedCount() int {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -323,3 +339,47 @@ func TestSettler(t *testing.T) {
 	cancel()
 	<-done
 }
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	reg := &testRegister{
+		settlementChan: make(chan settler.Settlement),
+		returnsChan:    make(chan settler.Return),
+	}
+
+	r := settler.NewRecorder(slog.New(slog.NewTextHandler(io.Discard, nil)), reg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := r.Start(ctx)
+
+	for i := 0; i < 5; i++ {
+		reg.settlementChan <- settler.Settlement{
+			CommitmentIdx: big.NewInt(int64(i + 1)).Bytes(),
+			TxHash:        "0x1234",
+			BlockNum:      100,
+			Builder:       "0x1234",
+			Amount:        1000,
+			BidID:         common.HexToHash(fmt.Sprintf("0x%02d", i)).Bytes(),
+			Type:          settler.SettlementTypeReward,
+		}
+	}
+
+	reg.returnsChan <- settler.Return{
+		BidIDs: [][32]byte{
+			common.HexToHash("0x10"),
+			common.HexToHash("0x11"),
+		},
+	}
+
+	if err := waitForCount(5*time.Second, 7, reg.recordedCount); err != nil {
+		t.Fatal(err)
+	}
+
+	if count := reg.settlementsInitiatedCount(); count != 0 {
+		t.Fatalf("expected no settlement to be initiated, got %d", count)
+	}
+
+	cancel()
+	<-done
+}
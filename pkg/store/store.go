@@ -3,12 +3,17 @@ package store
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/lib/pq"
 	_ "github.com/lib/pq"
+	"github.com/primevprotocol/mev-oracle/pkg/l1Listener"
 	"github.com/primevprotocol/mev-oracle/pkg/settler"
 	"github.com/primevprotocol/mev-oracle/pkg/updater"
 )
@@ -20,6 +25,13 @@ EXCEPTION
     WHEN duplicate_object THEN null;
 END $$;`
 
+var winnerStatusType = `
+DO $$ BEGIN
+    CREATE TYPE winner_status AS ENUM ('ok', 'disputed', 'no_winner');
+EXCEPTION
+    WHEN duplicate_object THEN null;
+END $$;`
+
 var settlementsTable = `
 CREATE TABLE IF NOT EXISTS settlements (
     commitment_index BYTEA PRIMARY KEY,
@@ -32,16 +44,137 @@ CREATE TABLE IF NOT EXISTS settlements (
     chainhash BYTEA,
     nonce BIGINT,
     settled BOOLEAN,
-    decay_percentage BIGINT
+    decay_percentage BIGINT,
+    orphaned BOOLEAN DEFAULT false,
+    receipt_status TEXT,
+    reason JSONB,
+    decay_bps BIGINT,
+    decay_start_timestamp BIGINT,
+    decay_end_timestamp BIGINT,
+    commit_timestamp BIGINT
 );`
 
 var winnersTable = `
 CREATE TABLE IF NOT EXISTS winners (
     block_number BIGINT PRIMARY KEY,
+    block_hash BYTEA,
+    parent_hash BYTEA,
+    builder_address BYTEA,
+    builder_pubkey TEXT,
+    relay TEXT,
+    extra_data BYTEA,
+    processed BOOLEAN,
+    orphaned BOOLEAN DEFAULT false,
+    status winner_status DEFAULT 'ok',
+    candidates JSONB,
+    slot BIGINT,
+    proposer_index BIGINT
+);`
+
+var missedSlotsTable = `
+CREATE TABLE IF NOT EXISTS missed_slots (
+    slot BIGINT PRIMARY KEY,
+    block_number BIGINT
+);`
+
+var builderMappingsTable = `
+CREATE TABLE IF NOT EXISTS builder_mappings (
+    builder TEXT,
+    address BYTEA,
+    block_number BIGINT,
+    observed_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (builder, block_number)
+);`
+
+var shadowSettlementsTable = `
+CREATE TABLE IF NOT EXISTS shadow_settlements (
+    commitment_index BYTEA PRIMARY KEY,
+    bid_id BYTEA,
+    block_number BIGINT,
     builder_address BYTEA,
-    processed BOOLEAN
+    type settlement_type,
+    decay_percentage BIGINT,
+    recorded_at TIMESTAMP DEFAULT NOW()
+);`
+
+var commitmentIndexesTable = `
+CREATE TABLE IF NOT EXISTS commitment_indexes (
+    block_number BIGINT,
+    position INT,
+    commitment_index BYTEA,
+    PRIMARY KEY (block_number, position)
+);`
+
+var indexerCursorTable = `
+CREATE TABLE IF NOT EXISTS indexer_cursor (
+    name TEXT PRIMARY KEY,
+    block_number BIGINT
+);`
+
+var quarantinedBlocksTable = `
+CREATE TABLE IF NOT EXISTS quarantined_blocks (
+    block_number BIGINT PRIMARY KEY,
+    attempts INT,
+    last_error TEXT,
+    quarantined_at TIMESTAMP DEFAULT NOW()
+);`
+
+var parkedBlocksTable = `
+CREATE TABLE IF NOT EXISTS parked_blocks (
+    block_number BIGINT PRIMARY KEY,
+    builder TEXT,
+    parked_at TIMESTAMP DEFAULT NOW(),
+    expired BOOLEAN DEFAULT false
 );`
 
+var dryRunBlocksTable = `
+CREATE TABLE IF NOT EXISTS dry_run_blocks (
+    block_number BIGINT PRIMARY KEY,
+    quarantined BOOLEAN DEFAULT false,
+    last_error TEXT,
+    processed_at TIMESTAMP DEFAULT NOW()
+);`
+
+// migrations bring the tables created by earlier versions up to date. They
+// are run on every start, so they need to be idempotent.
+var migrations = []string{
+	"ALTER TABLE winners ADD COLUMN IF NOT EXISTS block_hash BYTEA",
+	"ALTER TABLE winners ADD COLUMN IF NOT EXISTS parent_hash BYTEA",
+	"ALTER TABLE winners ADD COLUMN IF NOT EXISTS orphaned BOOLEAN DEFAULT false",
+	"ALTER TABLE settlements ADD COLUMN IF NOT EXISTS orphaned BOOLEAN DEFAULT false",
+	"ALTER TABLE winners ADD COLUMN IF NOT EXISTS builder_pubkey TEXT",
+	"ALTER TABLE winners ADD COLUMN IF NOT EXISTS relay TEXT",
+	"ALTER TABLE winners ADD COLUMN IF NOT EXISTS status winner_status DEFAULT 'ok'",
+	"ALTER TABLE winners ADD COLUMN IF NOT EXISTS candidates JSONB",
+	"ALTER TABLE winners ADD COLUMN IF NOT EXISTS extra_data BYTEA",
+	"ALTER TYPE winner_status ADD VALUE IF NOT EXISTS 'no_winner'",
+	"ALTER TABLE winners ADD COLUMN IF NOT EXISTS slot BIGINT",
+	"ALTER TABLE winners ADD COLUMN IF NOT EXISTS proposer_index BIGINT",
+	"ALTER TABLE settlements ADD COLUMN IF NOT EXISTS receipt_status TEXT",
+	"ALTER TABLE settlements ADD COLUMN IF NOT EXISTS reason JSONB",
+	"ALTER TABLE settlements ADD COLUMN IF NOT EXISTS decay_bps BIGINT",
+	"ALTER TABLE settlements ADD COLUMN IF NOT EXISTS decay_start_timestamp BIGINT",
+	"ALTER TABLE settlements ADD COLUMN IF NOT EXISTS decay_end_timestamp BIGINT",
+	"ALTER TABLE settlements ADD COLUMN IF NOT EXISTS commit_timestamp BIGINT",
+}
+
+// ErrNotDisputed is returned when resolving a block which is not disputed.
+var ErrNotDisputed = errors.New("block is not disputed")
+
+// ErrNotQuarantined is returned when retrying a block which is not
+// quarantined.
+var ErrNotQuarantined = errors.New("block is not quarantined")
+
+// ErrNotCandidate is returned when resolving a disputed block with a builder
+// which none of the winner sources reported.
+var ErrNotCandidate = errors.New("builder is not a candidate")
+
+// winnerPollInterval is the interval at which the unprocessed winners are
+// queried again without a change made by the store. It picks up the winners
+// released by other processes, such as the disputes and quarantine commands,
+// which cannot trigger the subscription of the node.
+const winnerPollInterval = 10 * time.Second
+
 type Store struct {
 	db       *sql.DB
 	winnerT  chan struct{}
@@ -50,13 +183,33 @@ type Store struct {
 }
 
 func NewStore(db *sql.DB) (*Store, error) {
-	for _, table := range []string{settlementType, settlementsTable, winnersTable} {
+	for _, table := range []string{
+		settlementType,
+		winnerStatusType,
+		settlementsTable,
+		winnersTable,
+		missedSlotsTable,
+		builderMappingsTable,
+		shadowSettlementsTable,
+		commitmentIndexesTable,
+		indexerCursorTable,
+		quarantinedBlocksTable,
+		parkedBlocksTable,
+		dryRunBlocksTable,
+	} {
 		_, err := db.Exec(table)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	for _, migration := range migrations {
+		_, err := db.Exec(migration)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &Store{
 		db:       db,
 		winnerT:  make(chan struct{}),
@@ -65,6 +218,13 @@ func NewStore(db *sql.DB) (*Store, error) {
 	}, nil
 }
 
+// NewReader returns a store reading the database of another oracle. The
+// tables are neither created nor migrated and only the read methods which
+// do not subscribe to changes can be used.
+func NewReader(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
 func (s *Store) triggerWinner() {
 	select {
 	case s.winnerT <- struct{}{}:
@@ -86,38 +246,254 @@ func (s *Store) triggerReturn() {
 	}
 }
 
-func (s *Store) RegisterWinner(ctx context.Context, blockNum int64, winner string) error {
-	insertStr := "INSERT INTO winners (block_number, builder_address, processed) VALUES ($1, $2, $3)"
+// RegisterWinner adds the winner of a block along with the slots missed
+// before it. Registering an already known block is a no-op unless the block
+// was orphaned by a reorg, in which case the winner is replaced by the one of
+// the new canonical block.
+func (s *Store) RegisterWinner(ctx context.Context, winner l1Listener.Winner) error {
+	insertStr := `
+		INSERT INTO winners (
+			block_number, block_hash, parent_hash, builder_address, builder_pubkey, relay,
+			extra_data, processed, orphaned, status, candidates, slot, proposer_index
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, false, false, $8, $9, $10, $11)
+		ON CONFLICT (block_number) DO UPDATE SET
+			block_hash = EXCLUDED.block_hash,
+			parent_hash = EXCLUDED.parent_hash,
+			builder_address = EXCLUDED.builder_address,
+			builder_pubkey = EXCLUDED.builder_pubkey,
+			relay = EXCLUDED.relay,
+			extra_data = EXCLUDED.extra_data,
+			processed = false,
+			orphaned = false,
+			status = EXCLUDED.status,
+			candidates = EXCLUDED.candidates,
+			slot = EXCLUDED.slot,
+			proposer_index = EXCLUDED.proposer_index
+		WHERE winners.orphaned = true`
+
+	status := winner.Status
+	if status == "" {
+		status = l1Listener.WinnerStatusOK
+	}
+
+	var candidates interface{}
+	if len(winner.Candidates) > 0 {
+		buf, err := json.Marshal(winner.Candidates)
+		if err != nil {
+			return err
+		}
+		candidates = string(buf)
+	}
+
+	var (
+		slot, proposerIndex interface{}
+		missedSlots         []int64
+	)
+	if winner.Slot != nil {
+		slot, proposerIndex = int64(winner.Slot.Slot), int64(winner.Slot.ProposerIndex)
+		for _, missed := range winner.Slot.MissedSlots {
+			missedSlots = append(missedSlots, int64(missed))
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
 
-	_, err := s.db.ExecContext(ctx, insertStr, blockNum, winner, false)
+	_, err = tx.ExecContext(
+		ctx,
+		insertStr,
+		winner.BlockNumber,
+		winner.BlockHash.Bytes(),
+		winner.ParentHash.Bytes(),
+		winner.Builder,
+		winner.BuilderPubkey,
+		winner.Relay,
+		winner.ExtraData,
+		status,
+		candidates,
+		slot,
+		proposerIndex,
+	)
 	if err != nil {
 		return err
 	}
+
+	if len(missedSlots) > 0 {
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO missed_slots (slot, block_number)
+			SELECT unnest($1::BIGINT[]), $2
+			ON CONFLICT (slot) DO UPDATE SET block_number = EXCLUDED.block_number`,
+			pq.Array(missedSlots),
+			winner.BlockNumber,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
 	s.triggerWinner()
 	return nil
 }
 
+func (s *Store) LastWinnerBlock(ctx context.Context) (int64, error) {
+	var lastBlock int64
+	err := s.db.QueryRowContext(
+		ctx,
+		"SELECT COALESCE(MAX(block_number), 0) FROM winners WHERE orphaned = false",
+	).Scan(&lastBlock)
+	if err != nil {
+		return 0, err
+	}
+	return lastBlock, nil
+}
+
+func (s *Store) BlockHash(ctx context.Context, blockNum int64) (common.Hash, error) {
+	var blockHash []byte
+	err := s.db.QueryRowContext(
+		ctx,
+		"SELECT block_hash FROM winners WHERE block_number = $1 AND orphaned = false AND block_hash IS NOT NULL",
+		blockNum,
+	).Scan(&blockHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return common.Hash{}, ethereum.NotFound
+		}
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(blockHash), nil
+}
+
+// MarkOrphaned marks the winners from fromBlock onwards as orphaned along with
+// their settlements which were not yet posted. It returns the number of
+// settlements orphaned.
+func (s *Store) MarkOrphaned(ctx context.Context, fromBlock int64) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE winners SET orphaned = true WHERE block_number >= $1 AND orphaned = false",
+		fromBlock,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM missed_slots WHERE block_number >= $1", fromBlock)
+	if err != nil {
+		return 0, err
+	}
+
+	// The winners registered again for the blocks are processed afresh.
+	_, err = tx.ExecContext(ctx, "DELETE FROM quarantined_blocks WHERE block_number >= $1", fromBlock)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM parked_blocks WHERE block_number >= $1", fromBlock)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.ExecContext(
+		ctx,
+		`UPDATE settlements SET orphaned = true
+		WHERE block_number >= $1 AND orphaned = false AND chainhash IS NULL`,
+		fromBlock,
+	)
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// SubscribeWinners sends the unprocessed winners which are neither disputed,
+// quarantined nor parked. They are queried again whenever the winners change
+// through this store and every winnerPollInterval otherwise, so the same
+// winner can be sent more than once.
 func (s *Store) SubscribeWinners(ctx context.Context) <-chan updater.BlockWinner {
+	return s.subscribeWinners(
+		ctx,
+		`SELECT w.block_number, w.block_hash, w.builder_address, w.status = 'no_winner', COALESCE(p.expired, false)
+		FROM winners w
+		LEFT JOIN parked_blocks p ON p.block_number = w.block_number
+		WHERE w.processed = false AND w.orphaned = false AND w.status IN ('ok', 'no_winner')
+			AND w.block_number NOT IN (SELECT block_number FROM quarantined_blocks)
+			AND (p.expired IS NULL OR p.expired = true)
+		ORDER BY w.block_number`,
+	)
+}
+
+// SubscribeDryRunWinners sends the winners of the blocks from to to which
+// are not disputed and were not yet processed by a dry run, whether the node
+// processed them or not. It is queried again like SubscribeWinners.
+func (s *Store) SubscribeDryRunWinners(ctx context.Context, from, to int64) <-chan updater.BlockWinner {
+	return s.subscribeWinners(
+		ctx,
+		`SELECT w.block_number, w.block_hash, w.builder_address, w.status = 'no_winner', false
+		FROM winners w
+		WHERE w.block_number BETWEEN $1 AND $2
+			AND w.orphaned = false AND w.status IN ('ok', 'no_winner')
+			AND w.block_number NOT IN (SELECT block_number FROM dry_run_blocks)
+		ORDER BY w.block_number`,
+		from, to,
+	)
+}
+
+func (s *Store) subscribeWinners(ctx context.Context, query string, args ...any) <-chan updater.BlockWinner {
 	resChan := make(chan updater.BlockWinner)
 	go func() {
 		defer close(resChan)
 
+		ticker := time.NewTicker(winnerPollInterval)
+		defer ticker.Stop()
+
 	RETRY:
 		for {
-			results, err := s.db.QueryContext(
-				ctx,
-				"SELECT block_number, builder_address FROM winners WHERE processed = false",
-			)
+			readAt := time.Now()
+			results, err := s.db.QueryContext(ctx, query, args...)
 			if err != nil {
 				return
 			}
 			for results.Next() {
-				var bWinner updater.BlockWinner
-				err = results.Scan(&bWinner.BlockNumber, &bWinner.Winner)
+				var (
+					bWinner   = updater.BlockWinner{ReadAt: readAt}
+					blockHash []byte
+				)
+				err = results.Scan(
+					&bWinner.BlockNumber,
+					&blockHash,
+					&bWinner.Winner,
+					&bWinner.NoWinner,
+					&bWinner.BuilderUnregistered,
+				)
 				if err != nil {
 					_ = results.Close()
 					continue RETRY
 				}
+				bWinner.BlockHash = common.BytesToHash(blockHash)
 				select {
 				case <-ctx.Done():
 					_ = results.Close()
@@ -131,6 +507,7 @@ func (s *Store) SubscribeWinners(ctx context.Context) <-chan updater.BlockWinner
 			case <-ctx.Done():
 				return
 			case <-s.winnerT:
+			case <-ticker.C:
 			}
 		}
 	}()
@@ -138,10 +515,70 @@ func (s *Store) SubscribeWinners(ctx context.Context) <-chan updater.BlockWinner
 	return resChan
 }
 
+// ResolveDispute sets the winner of a disputed block to one of the builders
+// reported by its winner sources. The settlements of the block are processed
+// once the dispute is resolved, by the subscription of this store at once or
+// by the one of a node running in another process at its next poll.
+func (s *Store) ResolveDispute(ctx context.Context, blockNum int64, builder string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var candidates []byte
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT candidates FROM winners
+		WHERE block_number = $1 AND status = 'disputed' AND orphaned = false
+		FOR UPDATE`,
+		blockNum,
+	).Scan(&candidates)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotDisputed
+	}
+	if err != nil {
+		return err
+	}
+
+	var sources map[string]string
+	if len(candidates) > 0 {
+		if err := json.Unmarshal(candidates, &sources); err != nil {
+			return err
+		}
+	}
+	found := false
+	for _, candidate := range sources {
+		if candidate == builder {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w: %s", ErrNotCandidate, builder)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE winners SET builder_address = $2, status = 'ok' WHERE block_number = $1",
+		blockNum,
+		builder,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.triggerWinner()
+	return nil
+}
+
 func (s *Store) UpdateComplete(ctx context.Context, blockNum int64) error {
 	_, err := s.db.ExecContext(
 		ctx,
-		"UPDATE winners SET processed = true WHERE block_number = $1",
+		"UPDATE winners SET processed = true WHERE block_number = $1 AND orphaned = false",
 		blockNum,
 	)
 	if err != nil {
@@ -162,6 +599,91 @@ func (s *Store) AddSettlement(
 	settlementType settler.SettlementType,
 	decayPercentage int64,
 ) error {
+	return insertSettlement(ctx, s.db, updater.Settlement{
+		CommitmentIdx:   commitmentIdx,
+		TxHash:          txHash,
+		BlockNum:        blockNum,
+		Amount:          amount,
+		Builder:         builder,
+		BidID:           bidID,
+		Type:            settlementType,
+		DecayPercentage: decayPercentage,
+	})
+}
+
+// AddSettlements stores the settlements of a block and marks the block
+// processed in a single transaction. Storing the settlements of a block again
+// replaces the ones which are not posted yet, so a block can be reprocessed.
+// It returns updater.ErrStaleWinner without storing anything if the winner of
+// the block is no longer the one of the hash.
+func (s *Store) AddSettlements(
+	ctx context.Context,
+	blockNum int64,
+	blockHash common.Hash,
+	settlements []updater.Settlement,
+) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, settlement := range settlements {
+		if err := insertSettlement(ctx, tx, settlement); err != nil {
+			return err
+		}
+	}
+
+	result, err := tx.ExecContext(
+		ctx,
+		`UPDATE winners SET processed = true
+		WHERE block_number = $1 AND block_hash IS NOT DISTINCT FROM $2 AND orphaned = false`,
+		blockNum,
+		blockHashArg(blockHash),
+	)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return updater.ErrStaleWinner
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.triggerSettler()
+	return nil
+}
+
+// blockHashArg returns the hash as a query argument, which is NULL for the
+// winners registered without a hash.
+func blockHashArg(blockHash common.Hash) any {
+	if blockHash == (common.Hash{}) {
+		return nil
+	}
+	return blockHash.Bytes()
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func insertSettlement(ctx context.Context, db execer, settlement updater.Settlement) error {
+	var reason []byte
+	if settlement.Reason.Code != "" {
+		var err error
+		reason, err = json.Marshal(settlement.Reason)
+		if err != nil {
+			return err
+		}
+	}
+
 	columns := []string{
 		"commitment_index",
 		"transaction",
@@ -174,32 +696,58 @@ func (s *Store) AddSettlement(
 		"chainhash",
 		"nonce",
 		"decay_percentage",
+		"receipt_status",
+		"reason",
+		"decay_bps",
+		"decay_start_timestamp",
+		"decay_end_timestamp",
+		"commit_timestamp",
 	}
 	values := []interface{}{
-		commitmentIdx,
-		txHash,
-		blockNum,
-		builder,
-		settlementType,
-		amount,
-		bidID,
+		settlement.CommitmentIdx,
+		settlement.TxHash,
+		settlement.BlockNum,
+		settlement.Builder,
+		settlement.Type,
+		settlement.Amount,
+		settlement.BidID,
 		false,
 		nil,
 		0,
-		decayPercentage,
+		settlement.DecayPercentage,
+		sql.NullString{
+			String: string(settlement.ReceiptStatus),
+			Valid:  settlement.ReceiptStatus != "",
+		},
+		reason,
+		settlement.Decay.Bps,
+		settlement.Decay.Start,
+		settlement.Decay.End,
+		settlement.Decay.CommitTime,
 	}
 	placeholder := make([]string, len(values))
 	for i := range columns {
 		placeholder[i] = fmt.Sprintf("$%d", i+1)
 	}
 
+	// A settlement is replaced if it was orphaned by a reorg or if it is not
+	// posted yet, so that reprocessing a block is safe. Posted settlements
+	// are kept.
+	updates := make([]string, len(columns))
+	for i, column := range columns {
+		updates[i] = fmt.Sprintf("%s = EXCLUDED.%s", column, column)
+	}
+
 	insertStr := fmt.Sprintf(
-		"INSERT INTO settlements (%s) VALUES (%s)",
+		`INSERT INTO settlements (%s) VALUES (%s)
+		ON CONFLICT (commitment_index) DO UPDATE SET %s, orphaned = false
+		WHERE settlements.orphaned = true OR settlements.chainhash IS NULL`,
 		strings.Join(columns, ", "),
 		strings.Join(placeholder, ", "),
+		strings.Join(updates, ", "),
 	)
 
-	_, err := s.db.ExecContext(ctx, insertStr, values...)
+	_, err := db.ExecContext(ctx, insertStr, values...)
 	if err != nil {
 		return err
 	}
@@ -217,7 +765,7 @@ func (s *Store) SubscribeSettlements(ctx context.Context) <-chan settler.Settlem
 			queryStr := `
 				SELECT commitment_index, transaction, block_number, builder_address, amount, bid_id, type, decay_percentage
 				FROM settlements
-				WHERE settled = false AND chainhash IS NULL AND type != 'return'
+				WHERE settled = false AND chainhash IS NULL AND type != 'return' AND orphaned = false
 				ORDER BY block_number ASC`
 
 			results, err := s.db.QueryContext(ctx, queryStr)
@@ -275,7 +823,7 @@ func (s *Store) SubscribeReturns(ctx context.Context, limit int) <-chan settler.
 			queryStr := `
 				SELECT DISTINCT bid_id, block_number
 				FROM settlements
-				WHERE settled = false AND chainhash IS NULL AND type = 'return'
+				WHERE settled = false AND chainhash IS NULL AND type = 'return' AND orphaned = false
 					AND block_number < (SELECT MAX(block_number) FROM settlements WHERE settled = true)
 				ORDER BY block_number ASC`
 
@@ -347,7 +895,7 @@ func (s *Store) SettlementInitiated(
 ) error {
 	_, err := s.db.ExecContext(
 		ctx,
-		"UPDATE settlements SET chainhash = $1, nonce = $2 WHERE bid_id = ANY($3::BYTEA[])",
+		"UPDATE settlements SET chainhash = $1, nonce = $2 WHERE bid_id = ANY($3::BYTEA[]) AND orphaned = false",
 		txHash.Bytes(),
 		nonce,
 		pq.Array(bidIDs),
@@ -358,45 +906,216 @@ func (s *Store) SettlementInitiated(
 	return nil
 }
 
-func (s *Store) MarkSettlementComplete(ctx context.Context, nonce uint64) (int, error) {
-	result, err := s.db.ExecContext(
+// RecordShadowSettlements records the settlements of the bids as they would
+// have been posted in shadow mode and marks them settled, so that they are
+// not sent again to the recorder and the returns are released.
+func (s *Store) RecordShadowSettlements(ctx context.Context, bidIDs [][]byte) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.ExecContext(
 		ctx,
-		"UPDATE settlements SET settled = true WHERE settled = false AND nonce < $1 AND chainhash IS NOT NULL",
-		nonce,
+		`INSERT INTO shadow_settlements (
+			commitment_index, bid_id, block_number, builder_address, type, decay_percentage
+		)
+		SELECT commitment_index, bid_id, block_number, builder_address, type, decay_percentage
+		FROM settlements
+		WHERE bid_id = ANY($1::BYTEA[]) AND orphaned = false
+		ON CONFLICT (commitment_index) DO UPDATE SET
+			bid_id = EXCLUDED.bid_id,
+			block_number = EXCLUDED.block_number,
+			builder_address = EXCLUDED.builder_address,
+			type = EXCLUDED.type,
+			decay_percentage = EXCLUDED.decay_percentage,
+			recorded_at = NOW()`,
+		pq.Array(bidIDs),
 	)
 	if err != nil {
-		return 0, err
+		return err
 	}
-	count, err := result.RowsAffected()
+
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE settlements SET settled = true WHERE bid_id = ANY($1::BYTEA[]) AND orphaned = false",
+		pq.Array(bidIDs),
+	)
 	if err != nil {
-		return 0, err
+		return err
 	}
-	s.triggerReturn()
-	return int(count), nil
-}
 
-func (s *Store) LastNonce() (int64, error) {
-	var lastNonce int64
-	err := s.db.QueryRow("SELECT MAX(nonce) FROM settlements").Scan(&lastNonce)
-	if err != nil {
-		return 0, err
+	if err := tx.Commit(); err != nil {
+		return err
 	}
-	return lastNonce, nil
+	s.triggerSettler()
+	return nil
 }
 
-func (s *Store) PendingTxnCount() (int, error) {
-	var count int
-	err := s.db.QueryRow(
-		"SELECT COUNT(DISTINCT chainhash) FROM settlements WHERE chainhash IS NOT NULL AND settled = false",
-	).Scan(&count)
+// AddDryRunSettlements records the settlements derived for the block in
+// shadow_settlements and marks it processed by the dry run. Unlike
+// AddSettlements it writes neither the settlements nor the winners table, so
+// the settlers never post them and the block is still processed for real. It
+// returns updater.ErrStaleWinner like AddSettlements.
+func (s *Store) AddDryRunSettlements(
+	ctx context.Context,
+	blockNum int64,
+	blockHash common.Hash,
+	settlements []updater.Settlement,
+) error {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return 0, err
+		return err
 	}
-	return count, nil
-}
+	defer func() { _ = tx.Rollback() }()
 
-type BlockInfo struct {
-	BlockNumber     int64
+	for _, settlement := range settlements {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO shadow_settlements (
+				commitment_index, bid_id, block_number, builder_address, type, decay_percentage
+			) VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (commitment_index) DO UPDATE SET
+				bid_id = EXCLUDED.bid_id,
+				block_number = EXCLUDED.block_number,
+				builder_address = EXCLUDED.builder_address,
+				type = EXCLUDED.type,
+				decay_percentage = EXCLUDED.decay_percentage,
+				recorded_at = NOW()`,
+			settlement.CommitmentIdx,
+			settlement.BidID,
+			settlement.BlockNum,
+			settlement.Builder,
+			settlement.Type,
+			settlement.DecayPercentage,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO dry_run_blocks (block_number)
+		SELECT block_number FROM winners
+		WHERE block_number = $1 AND block_hash IS NOT DISTINCT FROM $2 AND orphaned = false
+		ON CONFLICT (block_number) DO UPDATE SET
+			quarantined = false, last_error = NULL, processed_at = NOW()`,
+		blockNum,
+		blockHashArg(blockHash),
+	)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return updater.ErrStaleWinner
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.triggerWinner()
+	return nil
+}
+
+// QuarantineDryRunBlock marks the block as failed by the dry run, so that it
+// is no longer subscribed by it, without quarantining it for the node.
+func (s *Store) QuarantineDryRunBlock(ctx context.Context, blockNum int64, lastErr string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO dry_run_blocks (block_number, quarantined, last_error) VALUES ($1, true, $2)
+		ON CONFLICT (block_number) DO UPDATE SET
+			quarantined = true, last_error = EXCLUDED.last_error, processed_at = NOW()`,
+		blockNum,
+		lastErr,
+	)
+	if err != nil {
+		return err
+	}
+	s.triggerWinner()
+	return nil
+}
+
+// ResetDryRun forgets the blocks from to to processed by previous dry runs,
+// so that they are processed again.
+func (s *Store) ResetDryRun(ctx context.Context, from, to int64) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		"DELETE FROM dry_run_blocks WHERE block_number BETWEEN $1 AND $2",
+		from, to,
+	)
+	return err
+}
+
+// DryRunProgress is the processing by a dry run of the winners of a range of
+// blocks.
+type DryRunProgress struct {
+	Winners            int
+	UnprocessedWinners int
+	DisputedWinners    int
+	QuarantinedWinners int
+}
+
+func (s *Store) DryRunProgress(ctx context.Context, from, to int64) (DryRunProgress, error) {
+	var p DryRunProgress
+	err := s.db.QueryRowContext(ctx, `
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE status IN ('ok', 'no_winner') AND d.block_number IS NULL),
+			COUNT(*) FILTER (WHERE status = 'disputed'),
+			COUNT(*) FILTER (WHERE d.quarantined = true)
+		FROM winners w
+		LEFT JOIN dry_run_blocks d ON d.block_number = w.block_number
+		WHERE w.block_number BETWEEN $1 AND $2 AND orphaned = false`,
+		from, to,
+	).Scan(&p.Winners, &p.UnprocessedWinners, &p.DisputedWinners, &p.QuarantinedWinners)
+	return p, err
+}
+
+func (s *Store) MarkSettlementComplete(ctx context.Context, nonce uint64) (int, error) {
+	result, err := s.db.ExecContext(
+		ctx,
+		"UPDATE settlements SET settled = true WHERE settled = false AND nonce < $1 AND chainhash IS NOT NULL",
+		nonce,
+	)
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	s.triggerReturn()
+	return int(count), nil
+}
+
+func (s *Store) LastNonce() (int64, error) {
+	var lastNonce int64
+	err := s.db.QueryRow("SELECT MAX(nonce) FROM settlements").Scan(&lastNonce)
+	if err != nil {
+		return 0, err
+	}
+	return lastNonce, nil
+}
+
+func (s *Store) PendingTxnCount() (int, error) {
+	var count int
+	err := s.db.QueryRow(
+		"SELECT COUNT(DISTINCT chainhash) FROM settlements WHERE chainhash IS NOT NULL AND settled = false",
+	).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+type BlockInfo struct {
+	BlockNumber     int64
 	Builder         string
 	NoOfCommitments int
 	NoOfBids        int
@@ -406,6 +1125,9 @@ type BlockInfo struct {
 	NoOfSlashes     int
 	TotalSlashes    sql.NullString
 	NoOfSettlements int
+	Slot            sql.NullInt64
+	ProposerIndex   sql.NullInt64
+	MissedSlots     int
 }
 
 func (s *Store) ProcessedBlocks(limit, offset int) ([]BlockInfo, error) {
@@ -419,22 +1141,26 @@ func (s *Store) ProcessedBlocks(limit, offset int) ([]BlockInfo, error) {
 			(SELECT SUM(amount) FROM (
 				SELECT DISTINCT ON (bid_id) bid_id, amount
 				FROM settlements sub_settlements
-				WHERE sub_settlements.block_number = winners.block_number
+				WHERE sub_settlements.block_number = winners.block_number AND sub_settlements.orphaned = false
 				ORDER BY bid_id, block_number
 			) AS distinct_amounts) AS total_amount,
 			COUNT(settlements.type = 'reward' OR NULL) AS reward_count,
 			SUM(settlements.amount) FILTER (WHERE settlements.type = 'reward') AS total_rewards,
 			COUNT(settlements.type = 'slash' OR NULL) AS slash_count,
 			SUM(settlements.amount) FILTER (WHERE settlements.type = 'slash') AS total_slashes,
-			COUNT(settlements.settled) FILTER (WHERE settlements.settled = true) AS settled_count
+			COUNT(settlements.settled) FILTER (WHERE settlements.settled = true) AS settled_count,
+			winners.slot,
+			winners.proposer_index,
+			(SELECT COUNT(*) FROM missed_slots
+				WHERE missed_slots.block_number = winners.block_number) AS missed_slot_count
 		FROM
 			winners
 		LEFT JOIN
-			settlements ON settlements.block_number = winners.block_number
+			settlements ON settlements.block_number = winners.block_number AND settlements.orphaned = false
 		WHERE
-			winners.processed = true
+			winners.processed = true AND winners.orphaned = false
 		GROUP BY
-			winners.block_number, winners.builder_address
+			winners.block_number, winners.builder_address, winners.slot, winners.proposer_index
 		ORDER BY
 			winners.block_number DESC
 		LIMIT $1 OFFSET $2`,
@@ -458,6 +1184,9 @@ func (s *Store) ProcessedBlocks(limit, offset int) ([]BlockInfo, error) {
 			&b.NoOfSlashes,
 			&b.TotalSlashes,
 			&b.NoOfSettlements,
+			&b.Slot,
+			&b.ProposerIndex,
+			&b.MissedSlots,
 		)
 		if err != nil {
 			return nil, err
@@ -467,6 +1196,403 @@ func (s *Store) ProcessedBlocks(limit, offset int) ([]BlockInfo, error) {
 	return blocks, nil
 }
 
+// QuarantineBlock excludes the block from the subscribed winners after its
+// processing failed the given number of times with lastErr as last error.
+func (s *Store) QuarantineBlock(ctx context.Context, blockNum int64, attempts int, lastErr string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO quarantined_blocks (block_number, attempts, last_error) VALUES ($1, $2, $3)
+		ON CONFLICT (block_number) DO UPDATE
+		SET attempts = EXCLUDED.attempts, last_error = EXCLUDED.last_error, quarantined_at = NOW()`,
+		blockNum,
+		attempts,
+		lastErr,
+	)
+	return err
+}
+
+// RetryQuarantined releases the quarantined block, so that it is processed
+// again by the updater.
+func (s *Store) RetryQuarantined(ctx context.Context, blockNum int64) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM quarantined_blocks WHERE block_number = $1", blockNum)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotQuarantined
+	}
+	s.triggerWinner()
+	return nil
+}
+
+// ParkBlock excludes the block from the subscribed winners until its builder
+// registers or the block expires. A block parked again keeps the time it was
+// first parked at.
+func (s *Store) ParkBlock(ctx context.Context, blockNum int64, builder string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO parked_blocks (block_number, builder) VALUES ($1, $2)
+		ON CONFLICT (block_number) DO NOTHING`,
+		blockNum,
+		builder,
+	)
+	return err
+}
+
+// ParkedBuilders returns the builders of the parked blocks which are not
+// expired.
+func (s *Store) ParkedBuilders(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		"SELECT DISTINCT builder FROM parked_blocks WHERE expired = false ORDER BY builder",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var builders []string
+	for rows.Next() {
+		var builder string
+		if err := rows.Scan(&builder); err != nil {
+			return nil, err
+		}
+		builders = append(builders, builder)
+	}
+	return builders, rows.Err()
+}
+
+// UnparkBlocks releases the parked blocks of the builder, so that they are
+// processed again by the updater.
+func (s *Store) UnparkBlocks(ctx context.Context, builder string) (int, error) {
+	result, err := s.db.ExecContext(
+		ctx,
+		"DELETE FROM parked_blocks WHERE builder = $1 AND expired = false",
+		builder,
+	)
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		s.triggerWinner()
+	}
+	return int(count), nil
+}
+
+// ExpireParkedBlocks releases the blocks parked for longer than the timeout,
+// so that the updater returns their commitments.
+func (s *Store) ExpireParkedBlocks(ctx context.Context, timeout time.Duration) (int, error) {
+	result, err := s.db.ExecContext(
+		ctx,
+		`UPDATE parked_blocks SET expired = true
+		WHERE expired = false AND parked_at <= NOW() - $1 * INTERVAL '1 millisecond'`,
+		timeout.Milliseconds(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		s.triggerWinner()
+	}
+	return int(count), nil
+}
+
+type QuarantinedBlock struct {
+	BlockNumber   int64
+	Builder       string
+	Attempts      int
+	LastError     string
+	QuarantinedAt time.Time
+}
+
+func (s *Store) QuarantinedBlocks(limit, offset int) ([]QuarantinedBlock, error) {
+	var blocks []QuarantinedBlock
+	rows, err := s.db.Query(`
+		SELECT q.block_number, COALESCE(w.builder_address, ''), q.attempts, q.last_error, q.quarantined_at
+		FROM quarantined_blocks q
+		LEFT JOIN winners w ON w.block_number = q.block_number
+		ORDER BY q.block_number DESC
+		LIMIT $1 OFFSET $2`,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var b QuarantinedBlock
+		err := rows.Scan(&b.BlockNumber, &b.Builder, &b.Attempts, &b.LastError, &b.QuarantinedAt)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks, rows.Err()
+}
+
+type DisputedBlock struct {
+	BlockNumber int64
+	Builder     string
+	Candidates  map[string]string
+}
+
+func (s *Store) DisputedBlocks(limit, offset int) ([]DisputedBlock, error) {
+	var blocks []DisputedBlock
+	rows, err := s.db.Query(`
+		SELECT block_number, builder_address, candidates
+		FROM winners
+		WHERE status = 'disputed' AND orphaned = false
+		ORDER BY block_number DESC
+		LIMIT $1 OFFSET $2`,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			b          DisputedBlock
+			candidates []byte
+		)
+		if err := rows.Scan(&b.BlockNumber, &b.Builder, &candidates); err != nil {
+			return nil, err
+		}
+		if candidates != nil {
+			if err := json.Unmarshal(candidates, &b.Candidates); err != nil {
+				return nil, err
+			}
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks, rows.Err()
+}
+
+// SettlementDetail is a stored settlement with the reason for its type. The
+// decay inputs are zero for the settlements stored before they were recorded.
+type SettlementDetail struct {
+	CommitmentIndex string
+	TxHash          string
+	BlockNumber     int64
+	Builder         string
+	Type            settler.SettlementType
+	Amount          uint64
+	DecayPercentage int64
+	DecayBps        int64
+	DecayStart      int64
+	DecayEnd        int64
+	CommitTime      int64
+	ReceiptStatus   string
+	Reason          updater.Reason
+	ChainHash       string
+	Settled         bool
+	Orphaned        bool
+}
+
+// Settlements returns the settlements with their reasons, either the one of
+// the commitment index if given or otherwise those of the block.
+func (s *Store) Settlements(commitmentIdx []byte, blockNum int64) ([]SettlementDetail, error) {
+	// A nil slice is not necessarily sent as NULL.
+	var idx any
+	if commitmentIdx != nil {
+		idx = commitmentIdx
+	}
+
+	rows, err := s.db.Query(`
+		SELECT
+			commitment_index, transaction, block_number, builder_address, type, amount,
+			decay_percentage, decay_bps, decay_start_timestamp, decay_end_timestamp, commit_timestamp,
+			receipt_status, reason, chainhash, settled, orphaned
+		FROM settlements
+		WHERE ($1::BYTEA IS NOT NULL AND commitment_index = $1)
+			OR ($1::BYTEA IS NULL AND block_number = $2)
+		ORDER BY block_number DESC, commitment_index`,
+		idx, blockNum,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var settlements []SettlementDetail
+	for rows.Next() {
+		var (
+			d             SettlementDetail
+			index         []byte
+			decayBps      sql.NullInt64
+			decayStart    sql.NullInt64
+			decayEnd      sql.NullInt64
+			commitTime    sql.NullInt64
+			receiptStatus sql.NullString
+			reason        []byte
+			chainHash     []byte
+		)
+		err := rows.Scan(
+			&index,
+			&d.TxHash,
+			&d.BlockNumber,
+			&d.Builder,
+			&d.Type,
+			&d.Amount,
+			&d.DecayPercentage,
+			&decayBps,
+			&decayStart,
+			&decayEnd,
+			&commitTime,
+			&receiptStatus,
+			&reason,
+			&chainHash,
+			&d.Settled,
+			&d.Orphaned,
+		)
+		if err != nil {
+			return nil, err
+		}
+		d.CommitmentIndex = common.Bytes2Hex(index)
+		d.DecayBps = decayBps.Int64
+		d.DecayStart = decayStart.Int64
+		d.DecayEnd = decayEnd.Int64
+		d.CommitTime = commitTime.Int64
+		d.ReceiptStatus = receiptStatus.String
+		if reason != nil {
+			if err := json.Unmarshal(reason, &d.Reason); err != nil {
+				return nil, err
+			}
+		}
+		if chainHash != nil {
+			d.ChainHash = common.BytesToHash(chainHash).Hex()
+		}
+		settlements = append(settlements, d)
+	}
+	return settlements, rows.Err()
+}
+
+// RecordBuilderMapping records the address of the builder in the oracle
+// contract as observed while processing the block. Nothing is recorded if the
+// address matches the last recorded one.
+func (s *Store) RecordBuilderMapping(
+	ctx context.Context,
+	builder string,
+	address common.Address,
+	blockNum int64,
+) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO builder_mappings (builder, address, block_number)
+		SELECT $1, $2::BYTEA, $3
+		WHERE $2::BYTEA IS DISTINCT FROM (
+			SELECT address FROM builder_mappings
+			WHERE builder = $1
+			ORDER BY block_number DESC
+			LIMIT 1
+		)
+		ON CONFLICT (builder, block_number) DO UPDATE SET address = EXCLUDED.address`,
+		builder,
+		address.Bytes(),
+		blockNum,
+	)
+	return err
+}
+
+// BuilderMapping is the address of a builder in the oracle contract from the
+// block at which it was first observed.
+type BuilderMapping struct {
+	Builder     string
+	Address     string
+	BlockNumber int64
+	ObservedAt  time.Time
+}
+
+// BuilderMappings returns the history of the builder addresses, latest first.
+// The mappings of all builders are returned if builder is empty.
+func (s *Store) BuilderMappings(builder string, limit, offset int) ([]BuilderMapping, error) {
+	var mappings []BuilderMapping
+	rows, err := s.db.Query(`
+		SELECT builder, address, block_number, observed_at
+		FROM builder_mappings
+		WHERE $1 = '' OR builder = $1
+		ORDER BY block_number DESC, builder
+		LIMIT $2 OFFSET $3`,
+		builder, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			m       BuilderMapping
+			address []byte
+		)
+		if err := rows.Scan(&m.Builder, &address, &m.BlockNumber, &m.ObservedAt); err != nil {
+			return nil, err
+		}
+		m.Address = common.BytesToAddress(address).Hex()
+		mappings = append(mappings, m)
+	}
+	return mappings, rows.Err()
+}
+
+// RangeProgress is the processing state of the blocks in a range.
+type RangeProgress struct {
+	Winners             int
+	UnprocessedWinners  int
+	DisputedWinners     int
+	QuarantinedWinners  int
+	ParkedWinners       int
+	UnpostedSettlements int
+}
+
+func (s *Store) RangeProgress(ctx context.Context, from, to int64) (RangeProgress, error) {
+	var p RangeProgress
+	err := s.db.QueryRowContext(ctx, `
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (
+				WHERE processed = false AND status IN ('ok', 'no_winner')
+					AND q.block_number IS NULL AND (p.expired IS NULL OR p.expired = true)
+			),
+			COUNT(*) FILTER (WHERE status = 'disputed'),
+			COUNT(*) FILTER (WHERE processed = false AND q.block_number IS NOT NULL),
+			COUNT(*) FILTER (WHERE processed = false AND p.expired = false)
+		FROM winners w
+		LEFT JOIN quarantined_blocks q ON q.block_number = w.block_number
+		LEFT JOIN parked_blocks p ON p.block_number = w.block_number
+		WHERE w.block_number BETWEEN $1 AND $2 AND orphaned = false`,
+		from, to,
+	).Scan(&p.Winners, &p.UnprocessedWinners, &p.DisputedWinners, &p.QuarantinedWinners, &p.ParkedWinners)
+	if err != nil {
+		return p, err
+	}
+
+	err = s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM settlements
+		WHERE block_number BETWEEN $1 AND $2 AND orphaned = false
+			AND chainhash IS NULL AND type != 'return'`,
+		from, to,
+	).Scan(&p.UnpostedSettlements)
+	if err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
 type CommitmentStats struct {
 	TotalCount                int
 	BidCount                  int
@@ -486,6 +1612,8 @@ func (s *Store) CommitmentStats() (CommitmentStats, error) {
 			COUNT(settled) FILTER (WHERE settled = true)
 		FROM
 			settlements
+		WHERE
+			orphaned = false
 	`).Scan(
 		&stats.TotalCount,
 		&stats.BidCount,
@@ -498,3 +1626,140 @@ func (s *Store) CommitmentStats() (CommitmentStats, error) {
 	}
 	return stats, nil
 }
+
+// ShadowDecisions returns the settlements recorded in shadow mode for the
+// blocks from to to.
+func (s *Store) ShadowDecisions(ctx context.Context, from, to int64) ([]settler.Decision, error) {
+	return s.decisions(
+		ctx,
+		`SELECT commitment_index, block_number, type, decay_percentage
+		FROM shadow_settlements
+		WHERE block_number BETWEEN $1 AND $2`,
+		from, to,
+	)
+}
+
+// PostedDecisions returns the settlements posted to the oracle contract for
+// the blocks from to to.
+func (s *Store) PostedDecisions(ctx context.Context, from, to int64) ([]settler.Decision, error) {
+	return s.decisions(
+		ctx,
+		`SELECT commitment_index, block_number, type, decay_percentage
+		FROM settlements
+		WHERE chainhash IS NOT NULL AND orphaned = false AND block_number BETWEEN $1 AND $2`,
+		from, to,
+	)
+}
+
+func (s *Store) decisions(ctx context.Context, query string, args ...any) ([]settler.Decision, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var decisions []settler.Decision
+	for rows.Next() {
+		var (
+			d               settler.Decision
+			index           []byte
+			decayPercentage sql.NullInt64
+		)
+		if err := rows.Scan(&index, &d.BlockNum, &d.Type, &decayPercentage); err != nil {
+			return nil, err
+		}
+		d.CommitmentIdx = common.Bytes2Hex(index)
+		if decayPercentage.Valid {
+			d.DecayPercentage = &decayPercentage.Int64
+		}
+		decisions = append(decisions, d)
+	}
+	return decisions, rows.Err()
+}
+
+// commitmentsCursor is the name of the cursor of the commitment indexer.
+const commitmentsCursor = "commitments"
+
+// IndexerCursor returns the last settlement chain block indexed by the
+// commitment indexer, or 0 if none was.
+func (s *Store) IndexerCursor(ctx context.Context) (uint64, error) {
+	var cursor uint64
+	err := s.db.QueryRowContext(
+		ctx,
+		"SELECT block_number FROM indexer_cursor WHERE name = $1",
+		commitmentsCursor,
+	).Scan(&cursor)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return cursor, err
+}
+
+// IndexCommitments replaces the commitment indexes of the L1 blocks and
+// moves the cursor of the commitment indexer forward to the given settlement
+// chain block.
+func (s *Store) IndexCommitments(ctx context.Context, commitments map[int64][][32]byte, cursor uint64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for blockNum, indexes := range commitments {
+		_, err := tx.ExecContext(ctx, "DELETE FROM commitment_indexes WHERE block_number = $1", blockNum)
+		if err != nil {
+			return err
+		}
+		for position, index := range indexes {
+			_, err := tx.ExecContext(
+				ctx,
+				"INSERT INTO commitment_indexes (block_number, position, commitment_index) VALUES ($1, $2, $3)",
+				blockNum,
+				position,
+				index[:],
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO indexer_cursor (name, block_number) VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE
+		SET block_number = GREATEST(indexer_cursor.block_number, EXCLUDED.block_number)`,
+		commitmentsCursor,
+		cursor,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CommitmentIndexes returns the indexed commitment indexes of the L1 block in
+// the order of the preconf contract.
+func (s *Store) CommitmentIndexes(blockNum int64) ([][32]byte, error) {
+	rows, err := s.db.Query(
+		"SELECT commitment_index FROM commitment_indexes WHERE block_number = $1 ORDER BY position",
+		blockNum,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes [][32]byte
+	for rows.Next() {
+		var index []byte
+		if err := rows.Scan(&index); err != nil {
+			return nil, err
+		}
+		var idx [32]byte
+		copy(idx[:], index)
+		indexes = append(indexes, idx)
+	}
+	return indexes, rows.Err()
+}
@@ -4,11 +4,16 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"math/big"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/google/go-cmp/cmp"
+	"github.com/primevprotocol/mev-oracle/pkg/l1Listener"
 	"github.com/primevprotocol/mev-oracle/pkg/settler"
 	"github.com/primevprotocol/mev-oracle/pkg/store"
 	"github.com/primevprotocol/mev-oracle/pkg/updater"
@@ -149,11 +154,46 @@ func TestStore(t *testing.T) {
 		}
 
 		for _, winner := range winners {
-			err = st.RegisterWinner(context.Background(), winner.BlockNumber, winner.Winner)
+			err = st.RegisterWinner(context.Background(), l1Listener.Winner{
+				BlockNumber: winner.BlockNumber,
+				BlockHash:   common.BigToHash(big.NewInt(winner.BlockNumber)),
+				Builder:     winner.Winner,
+			})
 			if err != nil {
 				t.Fatalf("Failed to register winner: %s", err)
 			}
 		}
+
+		// registering an already known block is a no-op
+		err = st.RegisterWinner(context.Background(), l1Listener.Winner{
+			BlockNumber: winners[0].BlockNumber,
+			BlockHash:   common.HexToHash("0xff"),
+			Builder:     winners[0].Winner,
+		})
+		if err != nil {
+			t.Fatalf("Failed to register winner again: %s", err)
+		}
+
+		blockHash, err := st.BlockHash(context.Background(), winners[0].BlockNumber)
+		if err != nil {
+			t.Fatalf("Failed to get block hash: %s", err)
+		}
+		if blockHash != common.BigToHash(big.NewInt(winners[0].BlockNumber)) {
+			t.Fatalf("Unexpected block hash %s", blockHash)
+		}
+
+		_, err = st.BlockHash(context.Background(), 100)
+		if !errors.Is(err, ethereum.NotFound) {
+			t.Fatalf("Expected not found error, got %v", err)
+		}
+
+		lastBlock, err := st.LastWinnerBlock(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to get last winner block: %s", err)
+		}
+		if lastBlock != winners[1].BlockNumber {
+			t.Fatalf("Expected last winner block %d, got %d", winners[1].BlockNumber, lastBlock)
+		}
 	})
 
 	t.Run("SubscribeWinners", func(t *testing.T) {
@@ -472,4 +512,761 @@ func TestStore(t *testing.T) {
 			t.Fatalf("Expected no of settlements 3, got %d", block.NoOfSettlements)
 		}
 	})
+
+	t.Run("MarkOrphaned", func(t *testing.T) {
+		st, err := store.NewStore(db)
+		if err != nil {
+			t.Fatalf("Failed to create store: %s", err)
+		}
+
+		err = st.UpdateComplete(context.Background(), winners[1].BlockNumber)
+		if err != nil {
+			t.Fatalf("Failed to update winner: %s", err)
+		}
+
+		err = st.RegisterWinner(context.Background(), l1Listener.Winner{
+			BlockNumber: 3,
+			BlockHash:   common.HexToHash("0x03"),
+			ParentHash:  common.BigToHash(big.NewInt(2)),
+			Builder:     winners[0].Winner,
+		})
+		if err != nil {
+			t.Fatalf("Failed to register winner: %s", err)
+		}
+
+		err = st.AddSettlement(
+			context.Background(),
+			[]byte{7},
+			common.HexToHash("0x07").String(),
+			3,
+			1000000,
+			winners[0].Winner,
+			common.HexToHash("0x07").Bytes(),
+			settler.SettlementTypeReward,
+			0,
+		)
+		if err != nil {
+			t.Fatalf("Failed to add settlement: %s", err)
+		}
+
+		count, err := st.MarkOrphaned(context.Background(), 3)
+		if err != nil {
+			t.Fatalf("Failed to mark orphaned: %s", err)
+		}
+		if count != 1 {
+			t.Fatalf("Expected 1 orphaned settlement, got %d", count)
+		}
+
+		lastBlock, err := st.LastWinnerBlock(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to get last winner block: %s", err)
+		}
+		if lastBlock != 2 {
+			t.Fatalf("Expected last winner block 2, got %d", lastBlock)
+		}
+
+		_, err = st.BlockHash(context.Background(), 3)
+		if !errors.Is(err, ethereum.NotFound) {
+			t.Fatalf("Expected not found error, got %v", err)
+		}
+
+		// the new canonical block replaces the orphaned one
+		err = st.RegisterWinner(context.Background(), l1Listener.Winner{
+			BlockNumber:   3,
+			BlockHash:     common.HexToHash("0x0303"),
+			ParentHash:    common.BigToHash(big.NewInt(2)),
+			Builder:       winners[1].Winner,
+			BuilderPubkey: "0xaa01",
+			Relay:         "http://relay",
+		})
+		if err != nil {
+			t.Fatalf("Failed to register winner: %s", err)
+		}
+
+		blockHash, err := st.BlockHash(context.Background(), 3)
+		if err != nil {
+			t.Fatalf("Failed to get block hash: %s", err)
+		}
+		if blockHash != common.HexToHash("0x0303") {
+			t.Fatalf("Unexpected block hash %s", blockHash)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		winner := <-st.SubscribeWinners(ctx)
+		if winner.BlockNumber != 3 || winner.Winner != winners[1].Winner {
+			t.Fatalf("Unexpected winner %v", winner)
+		}
+
+		// the orphaned settlement is replaced once the commitment is settled
+		// again for the new block
+		err = st.AddSettlement(
+			context.Background(),
+			[]byte{7},
+			common.HexToHash("0x07").String(),
+			3,
+			1000000,
+			winners[1].Winner,
+			common.HexToHash("0x07").Bytes(),
+			settler.SettlementTypeSlash,
+			0,
+		)
+		if err != nil {
+			t.Fatalf("Failed to add settlement: %s", err)
+		}
+
+		settlement := <-st.SubscribeSettlements(ctx)
+		if !bytes.Equal(settlement.CommitmentIdx, []byte{7}) || settlement.Type != settler.SettlementTypeSlash {
+			t.Fatalf("Unexpected settlement %v", settlement)
+		}
+	})
+
+	t.Run("Disputes", func(t *testing.T) {
+		st, err := store.NewStore(db)
+		if err != nil {
+			t.Fatalf("Failed to create store: %s", err)
+		}
+
+		err = st.UpdateComplete(context.Background(), 3)
+		if err != nil {
+			t.Fatalf("Failed to update winner: %s", err)
+		}
+
+		candidates := map[string]string{
+			"extra-data":    winners[0].Winner,
+			"fee-recipient": winners[1].Winner,
+		}
+		err = st.RegisterWinner(context.Background(), l1Listener.Winner{
+			BlockNumber: 4,
+			BlockHash:   common.HexToHash("0x04"),
+			ParentHash:  common.HexToHash("0x0303"),
+			Builder:     winners[0].Winner,
+			Status:      l1Listener.WinnerStatusDisputed,
+			Candidates:  candidates,
+		})
+		if err != nil {
+			t.Fatalf("Failed to register winner: %s", err)
+		}
+
+		disputed, err := st.DisputedBlocks(10, 0)
+		if err != nil {
+			t.Fatalf("Failed to get disputed blocks: %s", err)
+		}
+		if len(disputed) != 1 || disputed[0].BlockNumber != 4 {
+			t.Fatalf("Unexpected disputed blocks %v", disputed)
+		}
+		if diff := cmp.Diff(candidates, disputed[0].Candidates); diff != "" {
+			t.Fatalf("Unexpected candidates (-want +got):\n%s", diff)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		winnerC := st.SubscribeWinners(ctx)
+		select {
+		case winner := <-winnerC:
+			t.Fatalf("Unexpected winner %v for disputed block", winner)
+		case <-time.After(500 * time.Millisecond):
+		}
+
+		err = st.ResolveDispute(context.Background(), 4, "unknown")
+		if !errors.Is(err, store.ErrNotCandidate) {
+			t.Fatalf("Expected not candidate error, got %v", err)
+		}
+
+		err = st.ResolveDispute(context.Background(), 4, winners[1].Winner)
+		if err != nil {
+			t.Fatalf("Failed to resolve dispute: %s", err)
+		}
+
+		select {
+		case winner := <-winnerC:
+			if winner.BlockNumber != 4 || winner.Winner != winners[1].Winner {
+				t.Fatalf("Unexpected winner %v", winner)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for resolved winner")
+		}
+
+		err = st.ResolveDispute(context.Background(), 4, winners[0].Winner)
+		if !errors.Is(err, store.ErrNotDisputed) {
+			t.Fatalf("Expected not disputed error, got %v", err)
+		}
+
+		progress, err := st.RangeProgress(context.Background(), 3, 4)
+		if err != nil {
+			t.Fatalf("Failed to get range progress: %s", err)
+		}
+		want := store.RangeProgress{Winners: 2, UnprocessedWinners: 1, UnpostedSettlements: 1}
+		if diff := cmp.Diff(want, progress); diff != "" {
+			t.Fatalf("Unexpected range progress (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("NoWinner", func(t *testing.T) {
+		st, err := store.NewStore(db)
+		if err != nil {
+			t.Fatalf("Failed to create store: %s", err)
+		}
+
+		err = st.UpdateComplete(context.Background(), 4)
+		if err != nil {
+			t.Fatalf("Failed to update winner: %s", err)
+		}
+
+		err = st.RegisterWinner(context.Background(), l1Listener.Winner{
+			BlockNumber: 5,
+			BlockHash:   common.HexToHash("0x05"),
+			ParentHash:  common.HexToHash("0x04"),
+			Status:      l1Listener.WinnerStatusNoWinner,
+		})
+		if err != nil {
+			t.Fatalf("Failed to register winner: %s", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		winner := <-st.SubscribeWinners(ctx)
+		if winner.BlockNumber != 5 || !winner.NoWinner || winner.Winner != "" {
+			t.Fatalf("Unexpected winner %v", winner)
+		}
+	})
+
+	t.Run("Slots", func(t *testing.T) {
+		st, err := store.NewStore(db)
+		if err != nil {
+			t.Fatalf("Failed to create store: %s", err)
+		}
+
+		err = st.RegisterWinner(context.Background(), l1Listener.Winner{
+			BlockNumber: 6,
+			BlockHash:   common.HexToHash("0x06"),
+			ParentHash:  common.HexToHash("0x05"),
+			Builder:     winners[0].Winner,
+			Slot: &l1Listener.SlotInfo{
+				Slot:          100,
+				ProposerIndex: 7,
+				MissedSlots:   []uint64{98, 99},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to register winner: %s", err)
+		}
+
+		err = st.UpdateComplete(context.Background(), 6)
+		if err != nil {
+			t.Fatalf("Failed to update winner: %s", err)
+		}
+
+		blocks, err := st.ProcessedBlocks(1, 0)
+		if err != nil {
+			t.Fatalf("Failed to get processed blocks: %s", err)
+		}
+		if len(blocks) != 1 || blocks[0].BlockNumber != 6 {
+			t.Fatalf("Unexpected processed blocks %v", blocks)
+		}
+		if blocks[0].Slot.Int64 != 100 || blocks[0].ProposerIndex.Int64 != 7 || blocks[0].MissedSlots != 2 {
+			t.Fatalf("Unexpected slot info %v", blocks[0])
+		}
+	})
+
+	t.Run("AddSettlements", func(t *testing.T) {
+		st, err := store.NewStore(db)
+		if err != nil {
+			t.Fatalf("Failed to create store: %s", err)
+		}
+
+		err = st.RegisterWinner(context.Background(), l1Listener.Winner{
+			BlockNumber: 7,
+			BlockHash:   common.HexToHash("0x07"),
+			ParentHash:  common.HexToHash("0x06"),
+			Builder:     winners[0].Winner,
+		})
+		if err != nil {
+			t.Fatalf("Failed to register winner: %s", err)
+		}
+
+		blockSettlements := []updater.Settlement{
+			{
+				CommitmentIdx:   []byte{7, 1},
+				TxHash:          "0x71",
+				BlockNum:        7,
+				Amount:          10,
+				Builder:         winners[0].Winner,
+				BidID:           []byte{7, 1},
+				Type:            settler.SettlementTypeReward,
+				DecayPercentage: 10,
+				Decay: updater.Decay{
+					Bps:        1025,
+					Start:      1_700_000_000_000,
+					End:        1_700_000_012_000,
+					CommitTime: 1_700_000_001,
+				},
+				ReceiptStatus: updater.ReceiptStatusSuccess,
+				Reason:        updater.Reason{Code: updater.ReasonIncluded},
+			},
+			{
+				CommitmentIdx:   []byte{7, 2},
+				TxHash:          "0x72",
+				BlockNum:        7,
+				Amount:          20,
+				Builder:         winners[0].Winner,
+				BidID:           []byte{7, 2},
+				Type:            settler.SettlementTypeSlash,
+				DecayPercentage: 20,
+				Reason: updater.Reason{
+					Code:   updater.ReasonTxMissing,
+					Detail: "tx 0x72 missing",
+				},
+			},
+		}
+
+		// The settlements of a reorged block are not stored.
+		err = st.AddSettlements(context.Background(), 7, common.HexToHash("0x17"), blockSettlements)
+		if !errors.Is(err, updater.ErrStaleWinner) {
+			t.Fatalf("Expected stale winner error, got %v", err)
+		}
+
+		// Reprocessing the block replaces the settlements.
+		for i := 0; i < 2; i++ {
+			err = st.AddSettlements(context.Background(), 7, common.HexToHash("0x07"), blockSettlements)
+			if err != nil {
+				t.Fatalf("Failed to add settlements: %s", err)
+			}
+		}
+
+		blocks, err := st.ProcessedBlocks(1, 0)
+		if err != nil {
+			t.Fatalf("Failed to get processed blocks: %s", err)
+		}
+		if len(blocks) != 1 || blocks[0].BlockNumber != 7 {
+			t.Fatalf("Unexpected processed blocks %v", blocks)
+		}
+		if blocks[0].NoOfCommitments != 2 || blocks[0].NoOfRewards != 1 || blocks[0].NoOfSlashes != 1 {
+			t.Fatalf("Unexpected block info %v", blocks[0])
+		}
+
+		details, err := st.Settlements(nil, 7)
+		if err != nil {
+			t.Fatalf("Failed to get settlements: %s", err)
+		}
+		if len(details) != 2 {
+			t.Fatalf("Expected 2 settlements, got %v", details)
+		}
+		for i, d := range details {
+			if d.Reason != blockSettlements[i].Reason {
+				t.Fatalf("Expected reason %v, got %v", blockSettlements[i].Reason, d.Reason)
+			}
+			decay := blockSettlements[i].Decay
+			if d.DecayBps != decay.Bps ||
+				d.DecayStart != int64(decay.Start) ||
+				d.DecayEnd != int64(decay.End) ||
+				d.CommitTime != int64(decay.CommitTime) {
+				t.Fatalf("Expected decay %v, got %v", decay, d)
+			}
+		}
+
+		details, err = st.Settlements([]byte{7, 2}, 0)
+		if err != nil {
+			t.Fatalf("Failed to get settlements: %s", err)
+		}
+		if len(details) != 1 || details[0].Type != settler.SettlementTypeSlash {
+			t.Fatalf("Unexpected settlements %v", details)
+		}
+	})
+
+	t.Run("ShadowSettlements", func(t *testing.T) {
+		st, err := store.NewStore(db)
+		if err != nil {
+			t.Fatalf("Failed to create store: %s", err)
+		}
+
+		// The settlements of block 7 are added by the AddSettlements test.
+		err = st.RecordShadowSettlements(context.Background(), [][]byte{{7, 1}})
+		if err != nil {
+			t.Fatalf("Failed to record shadow settlements: %s", err)
+		}
+
+		decisions, err := st.ShadowDecisions(context.Background(), 7, 7)
+		if err != nil {
+			t.Fatalf("Failed to get shadow decisions: %s", err)
+		}
+		if len(decisions) != 1 ||
+			decisions[0].CommitmentIdx != "0701" ||
+			decisions[0].Type != settler.SettlementTypeReward ||
+			decisions[0].DecayPercentage == nil ||
+			*decisions[0].DecayPercentage != 10 {
+			t.Fatalf("Unexpected shadow decisions %v", decisions)
+		}
+
+		details, err := st.Settlements([]byte{7, 1}, 0)
+		if err != nil {
+			t.Fatalf("Failed to get settlements: %s", err)
+		}
+		if len(details) != 1 || !details[0].Settled || details[0].ChainHash != "" {
+			t.Fatalf("Expected the recorded settlement to be settled without posting, got %v", details)
+		}
+
+		posted, err := store.NewReader(db).PostedDecisions(context.Background(), 7, 7)
+		if err != nil {
+			t.Fatalf("Failed to get posted decisions: %s", err)
+		}
+		if len(posted) != 0 {
+			t.Fatalf("Expected no posted decisions, got %v", posted)
+		}
+	})
+
+	t.Run("DryRunSettlements", func(t *testing.T) {
+		st, err := store.NewStore(db)
+		if err != nil {
+			t.Fatalf("Failed to create store: %s", err)
+		}
+
+		for _, blockNum := range []int64{9, 10} {
+			err = st.RegisterWinner(context.Background(), l1Listener.Winner{
+				BlockNumber: blockNum,
+				BlockHash:   common.BigToHash(big.NewInt(blockNum)),
+				ParentHash:  common.BigToHash(big.NewInt(blockNum - 1)),
+				Builder:     winners[0].Winner,
+			})
+			if err != nil {
+				t.Fatalf("Failed to register winner: %s", err)
+			}
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// only the winners of the range are subscribed by the dry run
+		winnerC := st.SubscribeDryRunWinners(ctx, 9, 9)
+		winner := <-winnerC
+		if winner.BlockNumber != 9 || winner.BlockHash != common.BigToHash(big.NewInt(9)) {
+			t.Fatalf("Expected winner of block 9, got %v", winner)
+		}
+
+		err = st.AddDryRunSettlements(context.Background(), 9, winner.BlockHash, []updater.Settlement{
+			{
+				CommitmentIdx:   []byte{9, 1},
+				TxHash:          "0x91",
+				BlockNum:        9,
+				Amount:          10,
+				Builder:         winners[0].Winner,
+				BidID:           []byte{9, 1},
+				Type:            settler.SettlementTypeSlash,
+				DecayPercentage: 10,
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to add dry run settlements: %s", err)
+		}
+
+		select {
+		case winner := <-winnerC:
+			t.Fatalf("Unexpected winner %v after the dry run processed the range", winner)
+		case <-time.After(500 * time.Millisecond):
+		}
+
+		// the settler is only sent the settlements not yet posted
+		settlementC := st.SubscribeSettlements(ctx)
+		for {
+			var settlement settler.Settlement
+			select {
+			case settlement = <-settlementC:
+			case <-time.After(500 * time.Millisecond):
+			}
+			if settlement.BlockNum == 0 {
+				break
+			}
+			if settlement.BlockNum == 9 {
+				t.Fatalf("Unexpected dry run settlement %v sent to the settler", settlement)
+			}
+		}
+
+		details, err := st.Settlements(nil, 9)
+		if err != nil {
+			t.Fatalf("Failed to get settlements: %s", err)
+		}
+		if len(details) != 0 {
+			t.Fatalf("Expected no settlements, got %v", details)
+		}
+
+		decisions, err := st.ShadowDecisions(context.Background(), 9, 9)
+		if err != nil {
+			t.Fatalf("Failed to get shadow decisions: %s", err)
+		}
+		if len(decisions) != 1 || decisions[0].CommitmentIdx != "0901" {
+			t.Fatalf("Unexpected shadow decisions %v", decisions)
+		}
+
+		dryRun, err := st.DryRunProgress(context.Background(), 9, 9)
+		if err != nil {
+			t.Fatalf("Failed to get dry run progress: %s", err)
+		}
+		if dryRun != (store.DryRunProgress{Winners: 1}) {
+			t.Fatalf("Unexpected dry run progress %v", dryRun)
+		}
+
+		// the winner is still processed by the node
+		progress, err := st.RangeProgress(context.Background(), 9, 9)
+		if err != nil {
+			t.Fatalf("Failed to get range progress: %s", err)
+		}
+		if progress.UnprocessedWinners != 1 || progress.UnpostedSettlements != 0 {
+			t.Fatalf("Unexpected range progress %v", progress)
+		}
+
+		for _, blockNum := range []int64{9, 10} {
+			if err := st.UpdateComplete(context.Background(), blockNum); err != nil {
+				t.Fatalf("Failed to update winner: %s", err)
+			}
+		}
+	})
+
+	t.Run("CommitmentIndexes", func(t *testing.T) {
+		st, err := store.NewStore(db)
+		if err != nil {
+			t.Fatalf("Failed to create store: %s", err)
+		}
+
+		cursor, err := st.IndexerCursor(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to get indexer cursor: %s", err)
+		}
+		if cursor != 0 {
+			t.Fatalf("Expected no cursor, got %d", cursor)
+		}
+
+		err = st.IndexCommitments(context.Background(), map[int64][][32]byte{
+			20: {{2, 1}, {2, 2}},
+			21: {{2, 3}},
+		}, 100)
+		if err != nil {
+			t.Fatalf("Failed to index commitments: %s", err)
+		}
+
+		// Reindexing a block replaces its commitments and the cursor only
+		// moves forward.
+		err = st.IndexCommitments(context.Background(), map[int64][][32]byte{
+			20: {{2, 2}, {2, 1}, {2, 4}},
+		}, 50)
+		if err != nil {
+			t.Fatalf("Failed to index commitments: %s", err)
+		}
+
+		cursor, err = st.IndexerCursor(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to get indexer cursor: %s", err)
+		}
+		if cursor != 100 {
+			t.Fatalf("Expected cursor 100, got %d", cursor)
+		}
+
+		indexes, err := st.CommitmentIndexes(20)
+		if err != nil {
+			t.Fatalf("Failed to get commitment indexes: %s", err)
+		}
+		if len(indexes) != 3 || indexes[0] != [32]byte{2, 2} || indexes[2] != [32]byte{2, 4} {
+			t.Fatalf("Unexpected commitment indexes %x", indexes)
+		}
+
+		indexes, err = st.CommitmentIndexes(22)
+		if err != nil {
+			t.Fatalf("Failed to get commitment indexes: %s", err)
+		}
+		if len(indexes) != 0 {
+			t.Fatalf("Expected no commitment indexes, got %x", indexes)
+		}
+	})
+
+	t.Run("BuilderMappings", func(t *testing.T) {
+		st, err := store.NewStore(db)
+		if err != nil {
+			t.Fatalf("Failed to create store: %s", err)
+		}
+
+		for _, m := range []struct {
+			address  string
+			blockNum int64
+		}{
+			{"0xabcd", 5},
+			{"0xabcd", 6},
+			{"0x1234", 7},
+		} {
+			err = st.RecordBuilderMapping(context.Background(), "test", common.HexToAddress(m.address), m.blockNum)
+			if err != nil {
+				t.Fatalf("Failed to record builder mapping: %s", err)
+			}
+		}
+
+		mappings, err := st.BuilderMappings("test", 10, 0)
+		if err != nil {
+			t.Fatalf("Failed to get builder mappings: %s", err)
+		}
+		if len(mappings) != 2 {
+			t.Fatalf("Expected 2 mappings, got %v", mappings)
+		}
+		if mappings[0].BlockNumber != 7 || mappings[0].Address != common.HexToAddress("0x1234").Hex() {
+			t.Fatalf("Unexpected latest mapping %v", mappings[0])
+		}
+		if mappings[1].BlockNumber != 5 || mappings[1].Address != common.HexToAddress("0xabcd").Hex() {
+			t.Fatalf("Unexpected first mapping %v", mappings[1])
+		}
+	})
+
+	t.Run("Quarantine", func(t *testing.T) {
+		st, err := store.NewStore(db)
+		if err != nil {
+			t.Fatalf("Failed to create store: %s", err)
+		}
+
+		err = st.RegisterWinner(context.Background(), l1Listener.Winner{
+			BlockNumber: 8,
+			BlockHash:   common.HexToHash("0x08"),
+			ParentHash:  common.HexToHash("0x07"),
+			Builder:     winners[0].Winner,
+		})
+		if err != nil {
+			t.Fatalf("Failed to register winner: %s", err)
+		}
+
+		// block 5 is still unprocessed since the NoWinner test
+		err = st.QuarantineBlock(context.Background(), 5, 3, "failed to get block")
+		if err != nil {
+			t.Fatalf("Failed to quarantine block: %s", err)
+		}
+
+		blocks, err := st.QuarantinedBlocks(10, 0)
+		if err != nil {
+			t.Fatalf("Failed to get quarantined blocks: %s", err)
+		}
+		if len(blocks) != 1 || blocks[0].BlockNumber != 5 {
+			t.Fatalf("Unexpected quarantined blocks %v", blocks)
+		}
+		if blocks[0].Attempts != 3 || blocks[0].LastError != "failed to get block" {
+			t.Fatalf("Unexpected quarantined block %v", blocks[0])
+		}
+
+		progress, err := st.RangeProgress(context.Background(), 5, 5)
+		if err != nil {
+			t.Fatalf("Failed to get range progress: %s", err)
+		}
+		if progress.UnprocessedWinners != 0 || progress.QuarantinedWinners != 1 {
+			t.Fatalf("Unexpected range progress %v", progress)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		winner := <-st.SubscribeWinners(ctx)
+		if winner.BlockNumber != 8 {
+			t.Fatalf("Expected winner of block 8, got %v", winner)
+		}
+
+		err = st.RetryQuarantined(context.Background(), 5)
+		if err != nil {
+			t.Fatalf("Failed to retry quarantined block: %s", err)
+		}
+
+		winner = <-st.SubscribeWinners(ctx)
+		if winner.BlockNumber != 5 {
+			t.Fatalf("Expected winner of block 5, got %v", winner)
+		}
+
+		err = st.RetryQuarantined(context.Background(), 5)
+		if !errors.Is(err, store.ErrNotQuarantined) {
+			t.Fatalf("Expected not quarantined error, got %v", err)
+		}
+	})
+
+	t.Run("ParkedBlocks", func(t *testing.T) {
+		st, err := store.NewStore(db)
+		if err != nil {
+			t.Fatalf("Failed to create store: %s", err)
+		}
+
+		// blocks 5 and 8 are still unprocessed since the Quarantine test
+		err = st.ParkBlock(context.Background(), 8, winners[0].Winner)
+		if err != nil {
+			t.Fatalf("Failed to park block: %s", err)
+		}
+
+		builders, err := st.ParkedBuilders(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to get parked builders: %s", err)
+		}
+		if diff := cmp.Diff([]string{winners[0].Winner}, builders); diff != "" {
+			t.Fatalf("Unexpected parked builders (-want +got):\n%s", diff)
+		}
+
+		progress, err := st.RangeProgress(context.Background(), 8, 8)
+		if err != nil {
+			t.Fatalf("Failed to get range progress: %s", err)
+		}
+		if progress.UnprocessedWinners != 0 || progress.ParkedWinners != 1 {
+			t.Fatalf("Unexpected range progress %v", progress)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		winnerC := st.SubscribeWinners(ctx)
+		if winner := <-winnerC; winner.BlockNumber != 5 {
+			t.Fatalf("Expected winner of block 5, got %v", winner)
+		}
+		select {
+		case winner := <-winnerC:
+			t.Fatalf("Unexpected winner %v for parked block", winner)
+		case <-time.After(500 * time.Millisecond):
+		}
+
+		waitWinner := func(blockNum int64) updater.BlockWinner {
+			t.Helper()
+			for {
+				select {
+				case winner := <-winnerC:
+					if winner.BlockNumber == blockNum {
+						return winner
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatalf("Timed out waiting for winner of block %d", blockNum)
+				}
+			}
+		}
+
+		count, err := st.ExpireParkedBlocks(context.Background(), time.Hour)
+		if err != nil {
+			t.Fatalf("Failed to expire parked blocks: %s", err)
+		}
+		if count != 0 {
+			t.Fatalf("Expected no expired block, got %d", count)
+		}
+
+		count, err = st.UnparkBlocks(context.Background(), winners[0].Winner)
+		if err != nil {
+			t.Fatalf("Failed to unpark blocks: %s", err)
+		}
+		if count != 1 {
+			t.Fatalf("Expected 1 unparked block, got %d", count)
+		}
+		if winner := waitWinner(8); winner.BuilderUnregistered {
+			t.Fatalf("Unexpected unregistered builder for unparked winner %v", winner)
+		}
+
+		err = st.ParkBlock(context.Background(), 8, winners[0].Winner)
+		if err != nil {
+			t.Fatalf("Failed to park block: %s", err)
+		}
+		count, err = st.ExpireParkedBlocks(context.Background(), 0)
+		if err != nil {
+			t.Fatalf("Failed to expire parked blocks: %s", err)
+		}
+		if count != 1 {
+			t.Fatalf("Expected 1 expired block, got %d", count)
+		}
+		if winner := waitWinner(8); !winner.BuilderUnregistered {
+			t.Fatalf("Expected unregistered builder for expired winner %v", winner)
+		}
+	})
 }
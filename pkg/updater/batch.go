@@ -0,0 +1,156 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	preconf "github.com/primevprotocol/contracts-abi/clients/PreConfCommitmentStore"
+)
+
+// DefaultBatchSize is the default number of commitments fetched in a single
+// JSON-RPC batch request.
+const DefaultBatchSize = 100
+
+// BatchCaller sends JSON-RPC batch requests, as implemented by *rpc.Client.
+type BatchCaller interface {
+	BatchCallContext(ctx context.Context, b []rpc.BatchElem) error
+}
+
+// BatchPreconf is a Preconf fetching the commitments of a block with JSON-RPC
+// batch requests of up to batchSize eth_calls. A chunk whose batch request
+// fails is fetched again with one call per commitment.
+type BatchPreconf struct {
+	*preconf.PreconfcommitmentstoreCallerSession
+	logger    *slog.Logger
+	client    BatchCaller
+	address   common.Address
+	abi       *abi.ABI
+	batchSize int
+}
+
+func NewBatchPreconf(
+	logger *slog.Logger,
+	session *preconf.PreconfcommitmentstoreCallerSession,
+	client BatchCaller,
+	address common.Address,
+	batchSize int,
+) (*BatchPreconf, error) {
+	parsed, err := preconf.PreconfcommitmentstoreMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	return &BatchPreconf{
+		PreconfcommitmentstoreCallerSession: session,
+		logger:                              logger,
+		client:                              client,
+		address:                             address,
+		abi:                                 parsed,
+		batchSize:                           batchSize,
+	}, nil
+}
+
+func (b *BatchPreconf) GetCommitments(
+	commitmentIdxs [][32]byte,
+) ([]preconf.PreConfCommitmentStorePreConfCommitment, error) {
+	commitments := make([]preconf.PreConfCommitmentStorePreConfCommitment, 0, len(commitmentIdxs))
+	for start := 0; start < len(commitmentIdxs); start += b.batchSize {
+		chunk := commitmentIdxs[start:min(start+b.batchSize, len(commitmentIdxs))]
+
+		fetched, err := b.batchGetCommitments(chunk)
+		if err != nil {
+			b.logger.Warn(
+				"batched commitment fetch failed, falling back to single calls",
+				"commitments", len(chunk),
+				"error", err,
+			)
+			fetched, err = b.singleGetCommitments(chunk)
+			if err != nil {
+				return nil, err
+			}
+		}
+		commitments = append(commitments, fetched...)
+	}
+	return commitments, nil
+}
+
+func (b *BatchPreconf) batchGetCommitments(
+	commitmentIdxs [][32]byte,
+) ([]preconf.PreConfCommitmentStorePreConfCommitment, error) {
+	ctx := b.CallOpts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// The calls are made against the same block as the single calls of the
+	// contract bindings.
+	block := "latest"
+	switch {
+	case b.CallOpts.Pending:
+		block = "pending"
+	case b.CallOpts.BlockNumber != nil:
+		block = hexutil.EncodeBig(b.CallOpts.BlockNumber)
+	}
+
+	results := make([]hexutil.Bytes, len(commitmentIdxs))
+	elems := make([]rpc.BatchElem, len(commitmentIdxs))
+	for i, idx := range commitmentIdxs {
+		data, err := b.abi.Pack("getCommitment", idx)
+		if err != nil {
+			return nil, err
+		}
+		elems[i] = rpc.BatchElem{
+			Method: "eth_call",
+			Args: []any{
+				map[string]any{
+					"from":  b.CallOpts.From,
+					"to":    b.address,
+					"input": hexutil.Bytes(data),
+				},
+				block,
+			},
+			Result: &results[i],
+		}
+	}
+
+	if err := b.client.BatchCallContext(ctx, elems); err != nil {
+		return nil, err
+	}
+
+	commitments := make([]preconf.PreConfCommitmentStorePreConfCommitment, len(commitmentIdxs))
+	for i, elem := range elems {
+		if elem.Error != nil {
+			return nil, fmt.Errorf("commitment %x: %w", commitmentIdxs[i], elem.Error)
+		}
+		out, err := b.abi.Unpack("getCommitment", results[i])
+		if err != nil {
+			return nil, fmt.Errorf("commitment %x: %w", commitmentIdxs[i], err)
+		}
+		commitments[i] = *abi.ConvertType(
+			out[0],
+			new(preconf.PreConfCommitmentStorePreConfCommitment),
+		).(*preconf.PreConfCommitmentStorePreConfCommitment)
+	}
+	return commitments, nil
+}
+
+func (b *BatchPreconf) singleGetCommitments(
+	commitmentIdxs [][32]byte,
+) ([]preconf.PreConfCommitmentStorePreConfCommitment, error) {
+	commitments := make([]preconf.PreConfCommitmentStorePreConfCommitment, len(commitmentIdxs))
+	for i, idx := range commitmentIdxs {
+		commitment, err := b.GetCommitment(idx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get commitment: %w", err)
+		}
+		commitments[i] = commitment
+	}
+	return commitments, nil
+}
@@ -0,0 +1,165 @@
+package updater_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/google/go-cmp/cmp"
+	preconf "github.com/primevprotocol/contracts-abi/clients/PreConfCommitmentStore"
+	"github.com/primevprotocol/mev-oracle/pkg/updater"
+)
+
+func TestBatchPreconf(t *testing.T) {
+	t.Parallel()
+
+	parsed, err := preconf.PreconfcommitmentstoreMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var idxs [][32]byte
+	var want []preconf.PreConfCommitmentStorePreConfCommitment
+	for i := int64(1); i <= 5; i++ {
+		idxs = append(idxs, getIdxBytes(i))
+		want = append(want, testCommitment(getIdxBytes(i)))
+	}
+
+	for _, tc := range []struct {
+		name        string
+		batchErr    error
+		elemErr     error
+		wantBatches int
+		wantCalls   int
+	}{
+		{
+			name:        "batched",
+			wantBatches: 3,
+		},
+		{
+			name:        "batch request fails",
+			batchErr:    errors.New("batch not supported"),
+			wantBatches: 3,
+			wantCalls:   5,
+		},
+		{
+			name:        "batch element fails",
+			elemErr:     errors.New("execution reverted"),
+			wantBatches: 3,
+			wantCalls:   5,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := &testContractClient{abi: parsed, batchErr: tc.batchErr, elemErr: tc.elemErr}
+			caller, err := preconf.NewPreconfcommitmentstoreCaller(common.HexToAddress("0x1"), client)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			bp, err := updater.NewBatchPreconf(
+				slog.New(slog.NewTextHandler(io.Discard, nil)),
+				&preconf.PreconfcommitmentstoreCallerSession{Contract: caller},
+				client,
+				common.HexToAddress("0x1"),
+				2,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := bp.GetCommitments(idxs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(want, got, cmp.Comparer(func(a, b *big.Int) bool {
+				return a.Cmp(b) == 0
+			})); diff != "" {
+				t.Fatalf("unexpected commitments (-want +got):\n%s", diff)
+			}
+			if client.batches != tc.wantBatches {
+				t.Fatalf("expected %d batches, got %d", tc.wantBatches, client.batches)
+			}
+			if client.calls != tc.wantCalls {
+				t.Fatalf("expected %d single calls, got %d", tc.wantCalls, client.calls)
+			}
+		})
+	}
+}
+
+func testCommitment(idx [32]byte) preconf.PreConfCommitmentStorePreConfCommitment {
+	return preconf.PreConfCommitmentStorePreConfCommitment{
+		Commiter:            common.HexToAddress("0x1234"),
+		Bid:                 10,
+		TxnHash:             common.Bytes2Hex(idx[:]),
+		CommitmentHash:      idx,
+		BidSignature:        []byte{},
+		CommitmentSignature: []byte{},
+		BlockCommitedAt:     new(big.Int).SetBytes(idx[:]),
+	}
+}
+
+// testContractClient serves getCommitment calls of the preconf contract both
+// as single calls and as batch requests.
+type testContractClient struct {
+	abi      *abi.ABI
+	batchErr error
+	elemErr  error
+	batches  int
+	calls    int
+}
+
+func (t *testContractClient) getCommitment(input []byte) ([]byte, error) {
+	method := t.abi.Methods["getCommitment"]
+	args, err := method.Inputs.Unpack(input[4:])
+	if err != nil {
+		return nil, err
+	}
+	return method.Outputs.Pack(testCommitment(args[0].([32]byte)))
+}
+
+func (t *testContractClient) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return []byte{1}, nil
+}
+
+func (t *testContractClient) CallContract(
+	_ context.Context,
+	call ethereum.CallMsg,
+	_ *big.Int,
+) ([]byte, error) {
+	t.calls++
+	return t.getCommitment(call.Data)
+}
+
+func (t *testContractClient) BatchCallContext(_ context.Context, b []rpc.BatchElem) error {
+	t.batches++
+	if t.batchErr != nil {
+		return t.batchErr
+	}
+	for i := range b {
+		if t.elemErr != nil {
+			b[i].Error = t.elemErr
+			continue
+		}
+		input := b[i].Args[0].(map[string]any)["input"].(hexutil.Bytes)
+		out, err := t.getCommitment(input)
+		if err != nil {
+			return err
+		}
+		*b[i].Result.(*hexutil.Bytes) = out
+	}
+	return nil
+}
+
+var _ bind.ContractCaller = (*testContractClient)(nil)
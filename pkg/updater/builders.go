@@ -0,0 +1,53 @@
+package updater
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultBuilderCacheTTL is the default duration for which the address of a
+// builder is used before it is fetched again from the oracle contract.
+const DefaultBuilderCacheTTL = time.Minute
+
+type builderEntry struct {
+	address   common.Address
+	fetchedAt time.Time
+}
+
+// builderCache caches the addresses of the builders registered in the oracle
+// contract. The contract emits no event when the address of a builder
+// changes, so the entries expire after the ttl and are fetched again. The
+// cache is only used by the goroutine committing the blocks.
+type builderCache struct {
+	oracle  Oracle
+	ttl     time.Duration
+	entries map[string]builderEntry
+	now     func() time.Time
+}
+
+func newBuilderCache(oracle Oracle, ttl time.Duration) *builderCache {
+	return &builderCache{
+		oracle:  oracle,
+		ttl:     ttl,
+		entries: make(map[string]builderEntry),
+		now:     time.Now,
+	}
+}
+
+// get returns the address of the builder. The fetched flag is set if the
+// address was fetched from the contract and previous is the address cached
+// before, or the zero address if the builder was not cached.
+func (c *builderCache) get(builder string) (address, previous common.Address, fetched bool, err error) {
+	entry, ok := c.entries[builder]
+	if ok && c.now().Sub(entry.fetchedAt) < c.ttl {
+		return entry.address, entry.address, false, nil
+	}
+
+	address, err = c.oracle.GetBuilder(builder)
+	if err != nil {
+		return common.Address{}, entry.address, false, err
+	}
+	c.entries[builder] = builderEntry{address: address, fetchedAt: c.now()}
+	return address, entry.address, true, nil
+}
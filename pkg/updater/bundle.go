@@ -0,0 +1,137 @@
+package updater
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BundleValidator decides whether the transactions of a commitment were
+// included in the block as committed. The transactions in the block are
+// given by their hash without the 0x prefix and their position. It returns
+// nil if they were, otherwise the reason for which they were not.
+type BundleValidator interface {
+	Validate(txnHashes []string, txnsInBlock map[string]int) *Reason
+}
+
+// Names of the bundle validation policies.
+const (
+	// BundlePolicyContiguous requires the transactions to be included next
+	// to each other in the committed order.
+	BundlePolicyContiguous = "contiguous"
+	// BundlePolicyOrdered requires the transactions to be included in the
+	// committed order, with other transactions allowed in between.
+	BundlePolicyOrdered = "ordered"
+	// BundlePolicyAnyOrder requires the transactions to be included
+	// anywhere in the block, in any order.
+	BundlePolicyAnyOrder = "any-order"
+	// BundlePolicyTopOfBlock requires the transactions to be included next
+	// to each other in the committed order at the start of the block.
+	BundlePolicyTopOfBlock = "top-of-block"
+)
+
+// BundlePolicies are the names of the supported bundle validation policies.
+var BundlePolicies = []string{
+	BundlePolicyContiguous,
+	BundlePolicyOrdered,
+	BundlePolicyAnyOrder,
+	BundlePolicyTopOfBlock,
+}
+
+// NewBundleValidator returns the validator of the named policy.
+func NewBundleValidator(policy string) (BundleValidator, error) {
+	switch policy {
+	case BundlePolicyContiguous:
+		return contiguousValidator{}, nil
+	case BundlePolicyOrdered:
+		return orderedValidator{}, nil
+	case BundlePolicyAnyOrder:
+		return anyOrderValidator{}, nil
+	case BundlePolicyTopOfBlock:
+		return contiguousValidator{topOfBlock: true}, nil
+	default:
+		return nil, fmt.Errorf(
+			"unknown bundle policy %q, expected one of %s",
+			policy,
+			strings.Join(BundlePolicies, ", "),
+		)
+	}
+}
+
+type contiguousValidator struct {
+	topOfBlock bool
+}
+
+func (v contiguousValidator) Validate(txnHashes []string, txnsInBlock map[string]int) *Reason {
+	first, found := txnsInBlock[txnHashes[0]]
+	if !found {
+		return txMissing(txnHashes[0])
+	}
+	if v.topOfBlock && first != 0 {
+		return &Reason{
+			Code:   ReasonTxPosition,
+			Detail: fmt.Sprintf("tx %s at position %d, expected 0", txnHashes[0], first),
+		}
+	}
+	for i, txnHash := range txnHashes {
+		posInBlock, found := txnsInBlock[txnHash]
+		if !found {
+			return txMissing(txnHash)
+		}
+		if posInBlock != first+i {
+			return &Reason{
+				Code:   ReasonTxPosition,
+				Detail: fmt.Sprintf("tx %s at position %d, expected %d", txnHash, posInBlock, first+i),
+			}
+		}
+	}
+	return nil
+}
+
+type orderedValidator struct{}
+
+func (orderedValidator) Validate(txnHashes []string, txnsInBlock map[string]int) *Reason {
+	prev := -1
+	for _, txnHash := range txnHashes {
+		posInBlock, found := txnsInBlock[txnHash]
+		if !found {
+			return txMissing(txnHash)
+		}
+		if posInBlock <= prev {
+			return &Reason{
+				Code:   ReasonTxPosition,
+				Detail: fmt.Sprintf("tx %s at position %d, expected after %d", txnHash, posInBlock, prev),
+			}
+		}
+		prev = posInBlock
+	}
+	return nil
+}
+
+type anyOrderValidator struct{}
+
+func (anyOrderValidator) Validate(txnHashes []string, txnsInBlock map[string]int) *Reason {
+	for _, txnHash := range txnHashes {
+		if _, found := txnsInBlock[txnHash]; !found {
+			return txMissing(txnHash)
+		}
+	}
+	return nil
+}
+
+func txMissing(txnHash string) *Reason {
+	return &Reason{Code: ReasonTxMissing, Detail: fmt.Sprintf("tx %s missing", txnHash)}
+}
+
+// SizedBundleValidator validates the commitments to a single transaction
+// and the bundles of multiple transactions with different policies.
+type SizedBundleValidator struct {
+	Single BundleValidator
+	Bundle BundleValidator
+}
+
+func (v SizedBundleValidator) Validate(txnHashes []string, txnsInBlock map[string]int) *Reason {
+	if len(txnHashes) == 1 {
+		return v.Single.Validate(txnHashes, txnsInBlock)
+	}
+	return v.Bundle.Validate(txnHashes, txnsInBlock)
+}
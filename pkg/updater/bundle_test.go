@@ -0,0 +1,147 @@
+package updater_test
+
+import (
+	"testing"
+
+	"github.com/primevprotocol/mev-oracle/pkg/updater"
+)
+
+func TestBundleValidators(t *testing.T) {
+	t.Parallel()
+
+	// synthetic block with the transactions a to f
+	txnsInBlock := make(map[string]int)
+	for i, txnHash := range []string{"a", "b", "c", "d", "e", "f"} {
+		txnsInBlock[txnHash] = i
+	}
+
+	bundles := map[string][]string{
+		"top of block":      {"a", "b"},
+		"contiguous":        {"c", "d", "e"},
+		"ordered with gaps": {"b", "d", "f"},
+		"out of order":      {"e", "c"},
+		"missing":           {"c", "x"},
+		"duplicate":         {"c", "c"},
+		"single":            {"d"},
+		"single first":      {"a"},
+	}
+
+	for _, tc := range []struct {
+		policy string
+		valid  []string
+	}{
+		{
+			policy: updater.BundlePolicyContiguous,
+			valid:  []string{"top of block", "contiguous", "single", "single first"},
+		},
+		{
+			policy: updater.BundlePolicyOrdered,
+			valid:  []string{"top of block", "contiguous", "ordered with gaps", "single", "single first"},
+		},
+		{
+			policy: updater.BundlePolicyAnyOrder,
+			valid: []string{
+				"top of block",
+				"contiguous",
+				"ordered with gaps",
+				"out of order",
+				"duplicate",
+				"single",
+				"single first",
+			},
+		},
+		{
+			policy: updater.BundlePolicyTopOfBlock,
+			valid:  []string{"top of block", "single first"},
+		},
+	} {
+		tc := tc
+		t.Run(tc.policy, func(t *testing.T) {
+			t.Parallel()
+
+			v, err := updater.NewBundleValidator(tc.policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			valid := make(map[string]bool)
+			for _, name := range tc.valid {
+				valid[name] = true
+			}
+			for name, bundle := range bundles {
+				if got := v.Validate(bundle, txnsInBlock) == nil; got != valid[name] {
+					t.Errorf("bundle %q: expected valid %t, got %t", name, valid[name], got)
+				}
+			}
+		})
+	}
+
+	t.Run("sized", func(t *testing.T) {
+		t.Parallel()
+
+		single, err := updater.NewBundleValidator(updater.BundlePolicyAnyOrder)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bundle, err := updater.NewBundleValidator(updater.BundlePolicyTopOfBlock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := updater.SizedBundleValidator{Single: single, Bundle: bundle}
+
+		if v.Validate([]string{"d"}, txnsInBlock) != nil {
+			t.Error("expected single transaction to be validated by the single policy")
+		}
+		if v.Validate([]string{"c", "d"}, txnsInBlock) == nil {
+			t.Error("expected bundle to be validated by the bundle policy")
+		}
+	})
+
+	t.Run("reasons", func(t *testing.T) {
+		t.Parallel()
+
+		contiguous, err := updater.NewBundleValidator(updater.BundlePolicyContiguous)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ordered, err := updater.NewBundleValidator(updater.BundlePolicyOrdered)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, tc := range []struct {
+			validator updater.BundleValidator
+			bundle    string
+			want      updater.Reason
+		}{
+			{
+				validator: contiguous,
+				bundle:    "out of order",
+				want:      updater.Reason{Code: updater.ReasonTxPosition, Detail: "tx c at position 2, expected 5"},
+			},
+			{
+				validator: ordered,
+				bundle:    "out of order",
+				want:      updater.Reason{Code: updater.ReasonTxPosition, Detail: "tx c at position 2, expected after 4"},
+			},
+			{
+				validator: ordered,
+				bundle:    "missing",
+				want:      updater.Reason{Code: updater.ReasonTxMissing, Detail: "tx x missing"},
+			},
+		} {
+			got := tc.validator.Validate(bundles[tc.bundle], txnsInBlock)
+			if got == nil || *got != tc.want {
+				t.Errorf("bundle %q: expected reason %v, got %v", tc.bundle, tc.want, got)
+			}
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := updater.NewBundleValidator("strict"); err == nil {
+			t.Fatal("expected error for unknown policy")
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package updater
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// BasisPoints is the resolution of the computed decay.
+const BasisPoints = 10_000
+
+// TimestampUnit is the unit of a timestamp.
+type TimestampUnit string
+
+const (
+	Seconds      TimestampUnit = "s"
+	Milliseconds TimestampUnit = "ms"
+)
+
+// TimestampUnits are the supported timestamp units.
+var TimestampUnits = []string{string(Seconds), string(Milliseconds)}
+
+func (u TimestampUnit) toMillis(timestamp uint64) uint64 {
+	if u == Seconds {
+		return timestamp * 1000
+	}
+	return timestamp
+}
+
+// DecayCurve is the decay of a bid between the decay start and end
+// timestamps of its commitment, as implemented by the oracle contract.
+type DecayCurve string
+
+const (
+	// DecayCurveLinear decays the bid linearly from the start to the end.
+	DecayCurveLinear DecayCurve = "linear"
+	// DecayCurveNone does not decay the bid, for the contract versions
+	// without decay.
+	DecayCurveNone DecayCurve = "none"
+)
+
+// DecayCurves are the supported decay curves.
+var DecayCurves = []string{string(DecayCurveLinear), string(DecayCurveNone)}
+
+// DecayConfig configures the computation of the decay of the commitments.
+type DecayConfig struct {
+	Curve DecayCurve
+	// CommitmentUnit is the unit of the decay timestamps of the commitments.
+	CommitmentUnit TimestampUnit
+	// BlockTimeUnit is the unit of the settlement chain block timestamps.
+	BlockTimeUnit TimestampUnit
+	// Precision is the value of a full decay expected by the oracle
+	// contract, e.g. 100 for percent or 10000 for basis points.
+	Precision int64
+}
+
+// DefaultDecayConfig is the decay of the current oracle contract, which
+// expects a linear decay in percent.
+var DefaultDecayConfig = DecayConfig{
+	Curve:          DecayCurveLinear,
+	CommitmentUnit: Milliseconds,
+	BlockTimeUnit:  Seconds,
+	Precision:      100,
+}
+
+// Validate returns an error if the curve, units or precision are not
+// supported.
+func (c DecayConfig) Validate() error {
+	switch c.Curve {
+	case DecayCurveLinear, DecayCurveNone:
+	default:
+		return fmt.Errorf("unknown decay curve %q", c.Curve)
+	}
+	for _, u := range []TimestampUnit{c.CommitmentUnit, c.BlockTimeUnit} {
+		if u != Seconds && u != Milliseconds {
+			return fmt.Errorf("unknown timestamp unit %q", u)
+		}
+	}
+	if c.Precision <= 0 || c.Precision > BasisPoints {
+		return fmt.Errorf("invalid decay precision %d", c.Precision)
+	}
+	return nil
+}
+
+// Decay is the decay of a commitment with the inputs it was computed from.
+type Decay struct {
+	// Scaled is the decay in the precision of the contract and Bps the
+	// decay in basis points, for display. Both are rounded once from the
+	// exact ratio.
+	Scaled int64
+	Bps    int64
+	// Start and End are the decay timestamps of the commitment and
+	// CommitTime is the timestamp of the settlement chain block in which
+	// the commitment was made, each in their configured unit.
+	Start      uint64
+	End        uint64
+	CommitTime uint64
+}
+
+// Compute returns the decay of a commitment with the given decay start and
+// end timestamps made in a block with the given timestamp.
+func (c DecayConfig) Compute(start, end, commitTime uint64) Decay {
+	d := Decay{Start: start, End: end, CommitTime: commitTime}
+
+	startMs := c.CommitmentUnit.toMillis(start)
+	endMs := c.CommitmentUnit.toMillis(end)
+	commitMs := c.BlockTimeUnit.toMillis(commitTime)
+
+	if c.Curve == DecayCurveNone || startMs >= endMs || startMs > commitMs {
+		return d
+	}
+	if commitMs >= endMs {
+		d.Scaled, d.Bps = c.Precision, BasisPoints
+		return d
+	}
+
+	// The product can exceed 64 bits for timestamps in milliseconds.
+	passed := new(big.Int).SetUint64(commitMs - startMs)
+	total := new(big.Int).SetUint64(endMs - startMs)
+	d.Scaled = roundDiv(new(big.Int).Mul(passed, big.NewInt(c.Precision)), total)
+	d.Bps = roundDiv(new(big.Int).Mul(passed, big.NewInt(BasisPoints)), total)
+	return d
+}
+
+// roundDiv returns x / y rounded half up for positive x and y.
+func roundDiv(x, y *big.Int) int64 {
+	q, r := new(big.Int).QuoRem(x, y, new(big.Int))
+	if r.Lsh(r, 1).Cmp(y) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	return q.Int64()
+}
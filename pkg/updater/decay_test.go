@@ -0,0 +1,135 @@
+package updater_test
+
+import (
+	"testing"
+
+	"github.com/primevprotocol/mev-oracle/pkg/updater"
+)
+
+func TestDecay(t *testing.T) {
+	t.Parallel()
+
+	const start = 1_700_000_000_000
+
+	for _, tc := range []struct {
+		name       string
+		config     updater.DecayConfig
+		start      uint64
+		end        uint64
+		commitTime uint64
+		bps        int64
+		scaled     int64
+	}{
+		{
+			name:       "half",
+			config:     updater.DefaultDecayConfig,
+			start:      start,
+			end:        start + 12_000,
+			commitTime: start/1000 + 6,
+			bps:        5000,
+			scaled:     50,
+		},
+		{
+			name:       "short window",
+			config:     updater.DecayConfig{Curve: updater.DecayCurveLinear, CommitmentUnit: updater.Milliseconds, BlockTimeUnit: updater.Milliseconds, Precision: 100},
+			start:      start,
+			end:        start + 800,
+			commitTime: start + 123,
+			bps:        1538,
+			scaled:     15,
+		},
+		{
+			name:       "basis points",
+			config:     updater.DecayConfig{Curve: updater.DecayCurveLinear, CommitmentUnit: updater.Milliseconds, BlockTimeUnit: updater.Milliseconds, Precision: updater.BasisPoints},
+			start:      start,
+			end:        start + 800,
+			commitTime: start + 123,
+			bps:        1538,
+			scaled:     1538,
+		},
+		{
+			// The exact ratio of 4.49951% is rounded to 4%, rounding the
+			// 450 bps instead would give 5%.
+			name:       "rounding boundary",
+			config:     updater.DecayConfig{Curve: updater.DecayCurveLinear, CommitmentUnit: updater.Milliseconds, BlockTimeUnit: updater.Milliseconds, Precision: 100},
+			start:      start,
+			end:        start + 10_000_000,
+			commitTime: start + 449_951,
+			bps:        450,
+			scaled:     4,
+		},
+		{
+			name:       "seconds",
+			config:     updater.DecayConfig{Curve: updater.DecayCurveLinear, CommitmentUnit: updater.Seconds, BlockTimeUnit: updater.Seconds, Precision: 100},
+			start:      start / 1000,
+			end:        start/1000 + 4,
+			commitTime: start/1000 + 1,
+			bps:        2500,
+			scaled:     25,
+		},
+		{
+			name:       "before start",
+			config:     updater.DefaultDecayConfig,
+			start:      start,
+			end:        start + 12_000,
+			commitTime: start/1000 - 1,
+		},
+		{
+			name:       "after end",
+			config:     updater.DefaultDecayConfig,
+			start:      start,
+			end:        start + 12_000,
+			commitTime: start/1000 + 20,
+			bps:        updater.BasisPoints,
+			scaled:     100,
+		},
+		{
+			name:       "empty window",
+			config:     updater.DefaultDecayConfig,
+			start:      start,
+			end:        start,
+			commitTime: start / 1000,
+		},
+		{
+			name:       "no decay",
+			config:     updater.DecayConfig{Curve: updater.DecayCurveNone, CommitmentUnit: updater.Milliseconds, BlockTimeUnit: updater.Seconds, Precision: 100},
+			start:      start,
+			end:        start + 12_000,
+			commitTime: start/1000 + 6,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := tc.config.Validate(); err != nil {
+				t.Fatal(err)
+			}
+
+			d := tc.config.Compute(tc.start, tc.end, tc.commitTime)
+			if d.Bps != tc.bps {
+				t.Errorf("expected %d bps, got %d", tc.bps, d.Bps)
+			}
+			if d.Start != tc.start || d.End != tc.end || d.CommitTime != tc.commitTime {
+				t.Errorf("expected the inputs to be recorded, got %+v", d)
+			}
+			if d.Scaled != tc.scaled {
+				t.Errorf("expected scaled decay %d, got %d", tc.scaled, d.Scaled)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range []updater.DecayConfig{
+			{Curve: "exponential", CommitmentUnit: updater.Milliseconds, BlockTimeUnit: updater.Seconds, Precision: 100},
+			{Curve: updater.DecayCurveLinear, CommitmentUnit: "us", BlockTimeUnit: updater.Seconds, Precision: 100},
+			{Curve: updater.DecayCurveLinear, CommitmentUnit: updater.Milliseconds, BlockTimeUnit: updater.Seconds, Precision: 0},
+		} {
+			if err := c.Validate(); err == nil {
+				t.Errorf("expected error for %+v", c)
+			}
+		}
+	})
+}
@@ -0,0 +1,11 @@
+package updater
+
+import "time"
+
+func SetParkedCheckInterval(interval time.Duration) func() {
+	oldInterval := parkedCheckInterval
+	parkedCheckInterval = interval
+	return func() {
+		parkedCheckInterval = oldInterval
+	}
+}
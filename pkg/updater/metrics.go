@@ -7,12 +7,30 @@ const (
 	subsystem        = "updater"
 )
 
+// Processing stages of the updater measured by the stage duration histogram.
+const (
+	stageL1Block           = "l1_block"
+	stageCommitmentIndexes = "commitment_indexes"
+	stageCommitments       = "commitments"
+	stageL2Block           = "l2_block"
+	stageReceipts          = "receipts"
+	stageCommit            = "commit"
+)
+
 type metrics struct {
-	UpdaterTriggerCount   prometheus.Counter
-	CommitmentsCount      prometheus.Counter
-	RewardsCount          prometheus.Counter
-	SlashesCount          prometheus.Counter
-	BlockCommitmentsCount prometheus.Counter
+	UpdaterTriggerCount       prometheus.Counter
+	CommitmentsCount          prometheus.Counter
+	RewardsCount              prometheus.Counter
+	SlashesCount              prometheus.Counter
+	BlockCommitmentsCount     prometheus.Counter
+	StageDuration             *prometheus.HistogramVec
+	BuilderAddressChangeCount prometheus.Counter
+	RevertedCommitmentsCount  prometheus.Counter
+	BlockFailuresCount        prometheus.Counter
+	QuarantinedBlocksCount    prometheus.Counter
+	ParkedBlocksCount         prometheus.Counter
+	UnparkedBlocksCount       prometheus.Counter
+	ExpiredParkedBlocksCount  prometheus.Counter
 }
 
 func newMetrics() *metrics {
@@ -57,6 +75,72 @@ func newMetrics() *metrics {
 			Help:      "Number of blocks for which commitments were processed",
 		},
 	)
+	m.StageDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "stage_duration_seconds",
+			Help:      "Duration of the block processing stages",
+			Buckets:   prometheus.ExponentialBuckets(0.005, 2, 12),
+		},
+		[]string{"stage"},
+	)
+	m.BuilderAddressChangeCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "builder_address_change_count",
+			Help:      "Number of times the address of a builder changed in the oracle contract",
+		},
+	)
+	m.RevertedCommitmentsCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "reverted_commitments_count",
+			Help:      "Number of commitments of the winner included in the block with reverted transactions",
+		},
+	)
+	m.BlockFailuresCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "block_failures_count",
+			Help:      "Number of failed attempts to process a block which are retried",
+		},
+	)
+	m.QuarantinedBlocksCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "quarantined_blocks_count",
+			Help:      "Number of blocks quarantined after reaching the maximum number of attempts",
+		},
+	)
+	m.ParkedBlocksCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "parked_blocks_count",
+			Help:      "Number of blocks parked as their builder was not registered",
+		},
+	)
+	m.UnparkedBlocksCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "unparked_blocks_count",
+			Help:      "Number of parked blocks released after their builder registered",
+		},
+	)
+	m.ExpiredParkedBlocksCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: defaultNamespace,
+			Subsystem: subsystem,
+			Name:      "expired_parked_blocks_count",
+			Help:      "Number of parked blocks whose commitments were returned after the builder timeout",
+		},
+	)
 	return m
 }
 
@@ -67,5 +151,13 @@ func (m *metrics) Collectors() []prometheus.Collector {
 		m.RewardsCount,
 		m.SlashesCount,
 		m.BlockCommitmentsCount,
+		m.StageDuration,
+		m.BuilderAddressChangeCount,
+		m.RevertedCommitmentsCount,
+		m.BlockFailuresCount,
+		m.QuarantinedBlocksCount,
+		m.ParkedBlocksCount,
+		m.UnparkedBlocksCount,
+		m.ExpiredParkedBlocksCount,
 	}
 }
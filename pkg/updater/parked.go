@@ -0,0 +1,93 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+)
+
+// DefaultBuilderTimeout is the default duration for which the blocks won by
+// a builder not registered in the oracle contract are parked before their
+// commitments are returned. It is three epochs, leaving a builder some time
+// to register while delaying the settlements of its blocks by minutes only.
+const DefaultBuilderTimeout = 3 * 32 * 12 * time.Second
+
+// parkedCheckInterval is the interval at which the builders of the parked
+// blocks are checked for registration.
+var parkedCheckInterval = 30 * time.Second
+
+// park defers the processing of the block until its builder registers in
+// the oracle contract or the builder timeout expires.
+func (u *Updater) park(ctx context.Context, winner BlockWinner) error {
+	if err := u.winnerRegister.ParkBlock(ctx, winner.BlockNumber, winner.Winner); err != nil {
+		return fmt.Errorf("failed to park block: %w", err)
+	}
+	u.metrics.ParkedBlocksCount.Inc()
+	u.logger.Warn(
+		"builder not registered, block parked",
+		"builder", winner.Winner,
+		"blockNumber", winner.BlockNumber,
+		"timeout", u.builderTimeout,
+	)
+	return nil
+}
+
+// watchParked checks the parked blocks until the context is done.
+func (u *Updater) watchParked(ctx context.Context) {
+	ticker := time.NewTicker(parkedCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := u.checkParked(ctx); err != nil && ctx.Err() == nil {
+			u.logger.Error("failed to check parked blocks", "error", err)
+		}
+	}
+}
+
+// checkParked releases the parked blocks of the builders registered since
+// and expires those parked for longer than the builder timeout, so that all
+// their commitments are returned. The oracle is queried directly as the
+// builder cache is owned by the goroutine committing the blocks.
+func (u *Updater) checkParked(ctx context.Context) error {
+	builders, err := u.winnerRegister.ParkedBuilders(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get parked builders: %w", err)
+	}
+
+	for _, builder := range builders {
+		_, err := u.rollupClient.GetBuilder(builder)
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			u.logger.Error("failed to get builder address", "builder", builder, "error", err)
+			continue
+		}
+
+		count, err := u.winnerRegister.UnparkBlocks(ctx, builder)
+		if err != nil {
+			return fmt.Errorf("failed to unpark blocks of builder %s: %w", builder, err)
+		}
+		u.metrics.UnparkedBlocksCount.Add(float64(count))
+		u.logger.Info("builder registered, blocks unparked", "builder", builder, "blocks", count)
+	}
+
+	count, err := u.winnerRegister.ExpireParkedBlocks(ctx, u.builderTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to expire parked blocks: %w", err)
+	}
+	if count > 0 {
+		u.metrics.ExpiredParkedBlocksCount.Add(float64(count))
+		u.logger.Warn("parked blocks expired, commitments returned", "blocks", count, "timeout", u.builderTimeout)
+	}
+	return nil
+}
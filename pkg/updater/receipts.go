@@ -0,0 +1,71 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ReceiptPolicy is the treatment of the rewarded commitments whose
+// transactions were included in the block but reverted.
+type ReceiptPolicy string
+
+const (
+	// ReceiptPolicyIgnore does not fetch the receipts, the commitments are
+	// settled by the position of their transactions alone.
+	ReceiptPolicyIgnore ReceiptPolicy = "ignore"
+	// ReceiptPolicyReward rewards the commitments with reverted
+	// transactions.
+	ReceiptPolicyReward ReceiptPolicy = "reward"
+	// ReceiptPolicySlash slashes the commitments with reverted transactions.
+	ReceiptPolicySlash ReceiptPolicy = "slash"
+	// ReceiptPolicyFlag rewards the commitments with reverted transactions
+	// and reports them for review.
+	ReceiptPolicyFlag ReceiptPolicy = "flag"
+)
+
+// ReceiptPolicies are the supported receipt policies.
+var ReceiptPolicies = []string{
+	string(ReceiptPolicyIgnore),
+	string(ReceiptPolicyReward),
+	string(ReceiptPolicySlash),
+	string(ReceiptPolicyFlag),
+}
+
+// ReceiptStatus is the execution status of the transactions of a commitment
+// included in the block. It is empty if the receipts were not fetched or none
+// of the transactions was included.
+type ReceiptStatus string
+
+const (
+	ReceiptStatusSuccess  ReceiptStatus = "success"
+	ReceiptStatusReverted ReceiptStatus = "reverted"
+)
+
+// receiptStatus returns the status of the committed transactions included in
+// the block. It is reverted if any of them reverted.
+func (u *Updater) receiptStatus(
+	ctx context.Context,
+	txnHashes string,
+	txnsInBlock map[string]int,
+) (ReceiptStatus, error) {
+	var status ReceiptStatus
+	for _, txnHash := range strings.Split(txnHashes, ",") {
+		if _, found := txnsInBlock[txnHash]; !found {
+			continue
+		}
+
+		receipt, err := u.l1Client.TransactionReceipt(ctx, common.HexToHash(txnHash))
+		if err != nil {
+			return "", fmt.Errorf("failed to get receipt of %s: %w", txnHash, err)
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			return ReceiptStatusReverted, nil
+		}
+		status = ReceiptStatusSuccess
+	}
+	return status, nil
+}
@@ -0,0 +1,88 @@
+package updater
+
+import (
+	"context"
+	"time"
+)
+
+// RetryPolicy is the handling of the blocks whose processing fails. They are
+// retried with an exponential backoff, starting at Backoff and bounded by
+// MaxBackoff, until MaxAttempts attempts failed. The following blocks are
+// processed while a block waits for its next attempt. The block is then
+// quarantined until an operator retries it. A zero MaxAttempts retries the
+// blocks forever.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+// DefaultRetryPolicy is the default retry policy of the failed blocks.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 10,
+	Backoff:     time.Second,
+	MaxBackoff:  5 * time.Minute,
+}
+
+// backoff returns the time to wait before the given attempt.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := p.Backoff
+	for i := 1; i < attempt && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, p.MaxBackoff)
+}
+
+// handleFailure quarantines the failed block once it reached the maximum
+// number of attempts and otherwise returns the backoff of its next attempt.
+// It is only called by the goroutine running the updater.
+func (u *Updater) handleFailure(
+	ctx context.Context,
+	blockNum int64,
+	failure error,
+) (backoff time.Duration, quarantined bool) {
+	attempts := u.attempts[blockNum] + 1
+	u.attempts[blockNum] = attempts
+
+	if u.retryPolicy.MaxAttempts > 0 && attempts >= u.retryPolicy.MaxAttempts {
+		err := u.winnerRegister.QuarantineBlock(ctx, blockNum, attempts, failure.Error())
+		if err == nil {
+			delete(u.attempts, blockNum)
+			u.metrics.QuarantinedBlocksCount.Inc()
+			u.logger.Error(
+				"block quarantined",
+				"blockNumber", blockNum,
+				"attempts", attempts,
+				"error", failure,
+			)
+			return 0, true
+		}
+		u.logger.Error("failed to quarantine block", "blockNumber", blockNum, "error", err)
+	}
+
+	backoff = u.retryPolicy.backoff(attempts)
+	u.metrics.BlockFailuresCount.Inc()
+	u.logger.Error(
+		"failed to process block",
+		"blockNumber", blockNum,
+		"attempts", attempts,
+		"retryIn", backoff,
+		"error", failure,
+	)
+	return backoff, false
+}
+
+// retry sends the winner of the failed block to be scheduled again once the
+// backoff elapsed.
+func retry(ctx context.Context, winner BlockWinner, backoff time.Duration, retries chan<- BlockWinner) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(backoff):
+	}
+
+	select {
+	case <-ctx.Done():
+	case retries <- winner:
+	}
+}
@@ -5,9 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"math"
 	"math/big"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
@@ -15,33 +16,119 @@ import (
 	preconf "github.com/primevprotocol/contracts-abi/clients/PreConfCommitmentStore"
 	"github.com/primevprotocol/mev-oracle/pkg/settler"
 	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/errgroup"
 )
 
 type BlockWinner struct {
 	BlockNumber int64
-	Winner      string
+	// BlockHash is the hash of the winner block, which is fetched and
+	// settled by it so that a block reorged after the winner was read is
+	// not settled. The winners registered without a hash are fetched by
+	// number.
+	BlockHash common.Hash
+	Winner    string
+	// NoWinner is set if no winner could be identified for the block, in
+	// which case all the commitments of the block are returned.
+	NoWinner bool
+	// BuilderUnregistered is set if the winner was not registered in the
+	// oracle contract within the builder timeout, in which case all the
+	// commitments of the block are returned.
+	BuilderUnregistered bool
+	// ReadAt is the time at which the winner was read by the register. The
+	// winners read before their block was committed, which a subscription
+	// emits again from a snapshot taken before the commit, are skipped. It
+	// is not checked if zero.
+	ReadAt time.Time
 }
 
+// Reason codes of the settlement decisions.
+const (
+	// ReasonIncluded is the reason for rewarding the winner's commitments
+	// whose transactions were included as required.
+	ReasonIncluded = "included"
+	// ReasonNoWinner is the reason for returning the commitments of blocks
+	// without an identified winner.
+	ReasonNoWinner = "no_winner"
+	// ReasonBuilderUnregistered is the reason for returning the commitments
+	// of blocks whose winner did not register in the oracle contract.
+	ReasonBuilderUnregistered = "builder_unregistered"
+	// ReasonNotWinner is the reason for returning the commitments of the
+	// providers who did not build the block.
+	ReasonNotWinner = "committer_not_winner"
+	// ReasonTxMissing is the reason for slashing the winner's commitments
+	// with a transaction missing from the block.
+	ReasonTxMissing = "tx_missing"
+	// ReasonTxPosition is the reason for slashing the winner's commitments
+	// with a transaction at a position other than required.
+	ReasonTxPosition = "tx_position"
+	// ReasonTxReverted is the reason for slashing the winner's commitments
+	// with reverted transactions.
+	ReasonTxReverted = "tx_reverted"
+)
+
+// Reason explains the settlement decision of a commitment.
+type Reason struct {
+	Code   string `json:"code"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Settlement is the settlement derived for a commitment of a winner block.
+type Settlement struct {
+	CommitmentIdx []byte
+	TxHash        string
+	BlockNum      int64
+	Amount        uint64
+	Builder       string
+	BidID         []byte
+	Type          settler.SettlementType
+	// DecayPercentage is the decay in the precision of the oracle contract
+	// and Decay the decay with its inputs and in basis points for display.
+	DecayPercentage int64
+	Decay           Decay
+	ReceiptStatus   ReceiptStatus
+	Reason          Reason
+}
+
+// ErrStaleWinner is returned by AddSettlements if the winner of the block is
+// no longer the one of the given hash, as the block was reorged meanwhile.
+var ErrStaleWinner = errors.New("stale winner")
+
 type WinnerRegister interface {
 	SubscribeWinners(ctx context.Context) <-chan BlockWinner
-	UpdateComplete(ctx context.Context, blockNum int64) error
-	AddSettlement(
-		ctx context.Context,
-		commitmentIdx []byte,
-		txHash string,
-		blockNum int64,
-		amount uint64,
-		builder string,
-		bidID []byte,
-		settlementType settler.SettlementType,
-		decayPercentage int64,
-	) error
+	// AddSettlements stores the settlements of the block and marks it
+	// processed atomically, unless the winner of the block is no longer the
+	// one of the hash. It is safe to call again for the same block.
+	AddSettlements(ctx context.Context, blockNum int64, blockHash common.Hash, settlements []Settlement) error
+	// RecordBuilderMapping records the address of the builder fetched while
+	// processing the block, if it differs from the last recorded one.
+	RecordBuilderMapping(ctx context.Context, builder string, address common.Address, blockNum int64) error
+	// QuarantineBlock excludes the block from the subscribed winners after
+	// its processing failed the given number of times.
+	QuarantineBlock(ctx context.Context, blockNum int64, attempts int, lastErr string) error
+	// ParkBlock excludes the block from the subscribed winners until its
+	// builder registers in the oracle contract.
+	ParkBlock(ctx context.Context, blockNum int64, builder string) error
+	// ParkedBuilders returns the builders of the parked blocks.
+	ParkedBuilders(ctx context.Context) ([]string, error)
+	// UnparkBlocks releases the parked blocks of the builder and returns
+	// their number.
+	UnparkBlocks(ctx context.Context, builder string) (int, error)
+	// ExpireParkedBlocks releases the blocks parked for longer than the
+	// timeout as winners with an unregistered builder and returns their
+	// number.
+	ExpireParkedBlocks(ctx context.Context, timeout time.Duration) (int, error)
 }
 
 type EVMClient interface {
 	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
 }
 
+type L1Client interface {
+	EVMClient
+	BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
 type Oracle interface {
 	GetBuilder(builder string) (common.Address, error)
 }
@@ -49,36 +136,85 @@ type Oracle interface {
 type Preconf interface {
 	GetCommitmentsByBlockNumber(blockNum *big.Int) ([][32]byte, error)
 	GetCommitment(commitmentIdx [32]byte) (preconf.PreConfCommitmentStorePreConfCommitment, error)
+	// GetCommitments returns the commitments of the indexes in the same
+	// order, fetching them in as few requests as possible.
+	GetCommitments(commitmentIdxs [][32]byte) ([]preconf.PreConfCommitmentStorePreConfCommitment, error)
 }
 
 type Updater struct {
-	logger               *slog.Logger
-	l1Client             EVMClient
-	l2Client             EVMClient
-	winnerRegister       WinnerRegister
-	preconfClient        Preconf
-	rollupClient         Oracle
-	builderIdentityCache map[string]common.Address
-	metrics              *metrics
-}
-
-func NewUpdater(
-	logger *slog.Logger,
-	l1Client EVMClient,
-	l2Client EVMClient,
-	winnerRegister WinnerRegister,
-	rollupClient Oracle,
-	preconfClient Preconf,
-) *Updater {
+	logger          *slog.Logger
+	l1Client        L1Client
+	l2Client        EVMClient
+	winnerRegister  WinnerRegister
+	preconfClient   Preconf
+	rollupClient    Oracle
+	builderCache    *builderCache
+	workers         chan struct{}
+	receiptPolicy   ReceiptPolicy
+	bundleValidator BundleValidator
+	decay           DecayConfig
+	retryPolicy     RetryPolicy
+	builderTimeout  time.Duration
+	metrics         *metrics
+
+	// The failed attempts of the blocks, only accessed by the goroutine
+	// running the updater.
+	attempts map[int64]int
+}
+
+// Options configures an updater.
+type Options struct {
+	Logger         *slog.Logger
+	L1Client       L1Client
+	L2Client       EVMClient
+	WinnerRegister WinnerRegister
+	RollupClient   Oracle
+	PreconfClient  Preconf
+	// Parallelism is the number of blocks and commitments fetched
+	// concurrently.
+	Parallelism int
+	// BuilderCacheTTL is the age after which the builder addresses are
+	// fetched again from the oracle contract.
+	BuilderCacheTTL time.Duration
+	// ReceiptPolicy settles the commitments with reverted transactions. The
+	// receipts of the committed transactions are not fetched if it is
+	// ignore.
+	ReceiptPolicy ReceiptPolicy
+	// BundleValidator accepts the inclusion of the transactions of the
+	// winner's commitments, which are rewarded if accepted and slashed
+	// otherwise.
+	BundleValidator BundleValidator
+	// Decay computes the decay of the commitments.
+	Decay DecayConfig
+	// RetryPolicy retries and quarantines the blocks which fail to be
+	// processed.
+	RetryPolicy RetryPolicy
+	// BuilderTimeout is the time for which the blocks won by builders not
+	// registered in the oracle contract are parked until the builder
+	// registers, before their commitments are returned. They are returned
+	// right away if it is zero.
+	BuilderTimeout time.Duration
+}
+
+// NewUpdater returns an updater processing the winners of the options'
+// register.
+func NewUpdater(opts *Options) *Updater {
 	return &Updater{
-		logger:               logger,
-		l1Client:             l1Client,
-		l2Client:             l2Client,
-		winnerRegister:       winnerRegister,
-		preconfClient:        preconfClient,
-		rollupClient:         rollupClient,
-		builderIdentityCache: make(map[string]common.Address),
-		metrics:              newMetrics(),
+		logger:          opts.Logger,
+		l1Client:        opts.L1Client,
+		l2Client:        opts.L2Client,
+		winnerRegister:  opts.WinnerRegister,
+		preconfClient:   opts.PreconfClient,
+		rollupClient:    opts.RollupClient,
+		builderCache:    newBuilderCache(opts.RollupClient, opts.BuilderCacheTTL),
+		workers:         make(chan struct{}, max(opts.Parallelism, 1)),
+		receiptPolicy:   opts.ReceiptPolicy,
+		bundleValidator: opts.BundleValidator,
+		decay:           opts.Decay,
+		retryPolicy:     opts.RetryPolicy,
+		builderTimeout:  opts.BuilderTimeout,
+		metrics:         newMetrics(),
+		attempts:        make(map[int64]int),
 	}
 }
 
@@ -86,168 +222,430 @@ func (u *Updater) Metrics() []prometheus.Collector {
 	return u.metrics.Collectors()
 }
 
+// blockJob is a winner block whose commitments are fetched. The job is
+// committed once done is closed.
+type blockJob struct {
+	winner      BlockWinner
+	done        chan struct{}
+	txnsInBlock map[string]int
+	commitments []fetchedCommitment
+	err         error
+}
+
+type fetchedCommitment struct {
+	index         [32]byte
+	commitment    preconf.PreConfCommitmentStorePreConfCommitment
+	decay         Decay
+	receiptStatus ReceiptStatus
+}
+
+// Start processes the winners. The commitments of the blocks are fetched
+// concurrently by the workers, while the settlements are written to the
+// store one block at a time in the order of the winners.
 func (u *Updater) Start(ctx context.Context) <-chan struct{} {
 	doneChan := make(chan struct{})
 
 	go func() {
 		defer close(doneChan)
 
-	RESTART:
-		cctx, unsub := context.WithCancel(ctx)
-		winnerChan := u.winnerRegister.SubscribeWinners(cctx)
+		var wg sync.WaitGroup
+		defer wg.Wait()
+		if u.builderTimeout > 0 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				u.watchParked(ctx)
+			}()
+		}
 
 		for {
-			select {
-			case <-ctx.Done():
-				unsub()
+			err := u.run(ctx)
+			if ctx.Err() != nil {
 				return
-			case winner, more := <-winnerChan:
-				if !more {
-					unsub()
-					goto RESTART
-				}
-				u.metrics.UpdaterTriggerCount.Inc()
-
-				err := func() error {
-					var err error
-					builderAddr, ok := u.builderIdentityCache[winner.Winner]
-					if !ok {
-						builderAddr, err = u.rollupClient.GetBuilder(winner.Winner)
-						if err != nil {
-							if errors.Is(err, ethereum.NotFound) {
-								u.logger.Warn("builder not registered", "builder", winner.Winner)
-								return u.winnerRegister.UpdateComplete(ctx, winner.BlockNumber)
-							}
-							return fmt.Errorf("failed to get builder address: %w", err)
-						}
-						u.builderIdentityCache[winner.Winner] = builderAddr
-					}
+			}
+			if err != nil {
+				u.logger.Error("failed to process settlements", "error", err)
+			}
+		}
+	}()
 
-					blk, err := u.l1Client.BlockByNumber(ctx, big.NewInt(winner.BlockNumber))
-					if err != nil {
-						return fmt.Errorf("failed to get block by number: %w", err)
-					}
+	return doneChan
+}
 
-					txnsInBlock := make(map[string]int)
-					for posInBlock, tx := range blk.Transactions() {
-						txnsInBlock[strings.TrimPrefix(tx.Hash().Hex(), "0x")] = posInBlock
-					}
+// run processes the winners until the subscription is closed. The failed
+// blocks are set aside until their next attempt, so that the following blocks
+// are processed meanwhile.
+func (u *Updater) run(ctx context.Context) error {
+	cctx, unsub := context.WithCancel(ctx)
+	defer unsub()
+
+	// The capacity bounds the number of blocks fetched ahead of the one
+	// being committed.
+	jobs := make(chan *blockJob, cap(u.workers))
+	// The subscription emits the unprocessed winners again on every new
+	// winner, so the blocks already queued are tracked with their hash to
+	// skip them unless they were reorged meanwhile. The committed blocks are
+	// tracked with their commit time until they can no longer be emitted by
+	// a snapshot taken before.
+	queued, committed := &sync.Map{}, &sync.Map{}
+	// The failed blocks stay queued while waiting for their next attempt.
+	retries := make(chan BlockWinner)
+	go u.schedule(cctx, jobs, retries, queued, committed)
+
+	for job := range jobs {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-job.done:
+		}
 
-					commitmentIndexes, err := u.preconfClient.GetCommitmentsByBlockNumber(
-						big.NewInt(winner.BlockNumber),
-					)
-					if err != nil {
-						return fmt.Errorf("failed to get commitments by block number: %w", err)
-					}
+		if err := u.commit(ctx, job); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if errors.Is(err, ErrStaleWinner) {
+				// The winner of the new block is queued once the
+				// subscription emits it.
+				u.logger.Info(
+					"winner block reorged",
+					"blockNumber", job.winner.BlockNumber,
+					"blockHash", job.winner.BlockHash.Hex(),
+				)
+				queued.CompareAndDelete(job.winner.BlockNumber, job.winner.BlockHash)
+				delete(u.attempts, job.winner.BlockNumber)
+				continue
+			}
+			backoff, quarantined := u.handleFailure(
+				ctx,
+				job.winner.BlockNumber,
+				fmt.Errorf("block %d with winner %q: %w", job.winner.BlockNumber, job.winner.Winner, err),
+			)
+			if quarantined {
+				queued.CompareAndDelete(job.winner.BlockNumber, job.winner.BlockHash)
+			} else {
+				go retry(cctx, job.winner, backoff, retries)
+			}
+			continue
+		}
+		committed.Store(job.winner.BlockNumber, time.Now())
+		queued.CompareAndDelete(job.winner.BlockNumber, job.winner.BlockHash)
+		delete(u.attempts, job.winner.BlockNumber)
+	}
+	return nil
+}
 
-					u.logger.Debug(
-						"commitment indexes",
-						"commitments_count", len(commitmentIndexes),
-						"txns_count", len(txnsInBlock),
-						"blockNumber", winner.BlockNumber,
-					)
+// schedule starts fetching the subscribed and the retried winners and queues
+// them in order. The queue is closed when the subscription ends.
+func (u *Updater) schedule(
+	ctx context.Context,
+	jobs chan<- *blockJob,
+	retries <-chan BlockWinner,
+	queued, committed *sync.Map,
+) {
+	defer close(jobs)
+
+	winnerChan := u.winnerRegister.SubscribeWinners(ctx)
+	for {
+		var winner BlockWinner
+		select {
+		case <-ctx.Done():
+			return
+		case winner = <-retries:
+		case w, more := <-winnerChan:
+			if !more {
+				return
+			}
+			if stale(w, committed) {
+				continue
+			}
+			if hash, ok := queued.Swap(w.BlockNumber, w.BlockHash); ok && hash == w.BlockHash {
+				continue
+			}
+			winner = w
+		}
+		u.metrics.UpdaterTriggerCount.Inc()
 
-					total, rewards, slashes := 0, 0, 0
-					for _, index := range commitmentIndexes {
-						commitment, err := u.preconfClient.GetCommitment(index)
-						if err != nil {
-							return fmt.Errorf("failed to get commitment: %w", err)
-						}
-
-						l2Block, err := u.l2Client.BlockByNumber(ctx, commitment.BlockCommitedAt)
-						if err != nil {
-							return fmt.Errorf("failed to get L2 Block: %w", err)
-						}
-						decayPercentage := computeDecayPercentage(commitment.DecayStartTimeStamp, commitment.DecayEndTimeStamp, l2Block.Header().Time)
-
-						settlementType := settler.SettlementTypeReturn
-
-						if commitment.Commiter.Cmp(builderAddr) == 0 {
-							commitmentTxnHashes := strings.Split(commitment.TxnHash, ",")
-							settlementType = settler.SettlementTypeReward
-
-							// Ensure Bundle is atomic and present in the block
-							for i := 0; i < len(commitmentTxnHashes); i++ {
-								posInBlock, found := txnsInBlock[commitmentTxnHashes[i]]
-								if !found || posInBlock != txnsInBlock[commitmentTxnHashes[0]]+i {
-									settlementType = settler.SettlementTypeSlash
-									break
-								}
-							}
-						}
-
-						err = u.winnerRegister.AddSettlement(
-							ctx,
-							index[:],
-							commitment.TxnHash,
-							winner.BlockNumber,
-							commitment.Bid,
-							commitment.Commiter.Hex(),
-							commitment.CommitmentHash[:],
-							settlementType,
-							decayPercentage,
-						)
-						if err != nil {
-							return fmt.Errorf("failed to add settlement: %w", err)
-						}
-
-						total++
-						switch settlementType {
-						case settler.SettlementTypeSlash:
-							slashes++
-						case settler.SettlementTypeReward:
-							rewards++
-						}
-					}
+		job := &blockJob{winner: winner, done: make(chan struct{})}
+		select {
+		case <-ctx.Done():
+			return
+		case jobs <- job:
+		}
+		go u.fetch(ctx, job)
+	}
+}
 
-					err = u.winnerRegister.UpdateComplete(ctx, winner.BlockNumber)
-					if err != nil {
-						return fmt.Errorf("failed to update completion of block updates: %w", err)
-					}
+// stale reports whether the winner was read before its block was committed.
+// The winners are read in order, so the blocks committed before the winner
+// was read can only be emitted again if registered anew and are forgotten.
+func stale(winner BlockWinner, committed *sync.Map) bool {
+	if winner.ReadAt.IsZero() {
+		return false
+	}
+	if at, ok := committed.Load(winner.BlockNumber); ok && winner.ReadAt.Before(at.(time.Time)) {
+		return true
+	}
+	committed.Range(func(blockNum, at any) bool {
+		if !winner.ReadAt.Before(at.(time.Time)) {
+			committed.Delete(blockNum)
+		}
+		return true
+	})
+	return false
+}
 
-					u.metrics.CommitmentsCount.Add(float64(total))
-					u.metrics.RewardsCount.Add(float64(rewards))
-					u.metrics.SlashesCount.Add(float64(slashes))
-					u.metrics.BlockCommitmentsCount.Inc()
+// fetch gets the transactions and the commitments of the job's block. The
+// settlement chain block of each commitment is fetched by one of the workers.
+func (u *Updater) fetch(ctx context.Context, job *blockJob) {
+	defer close(job.done)
+
+	job.err = func() error {
+		start := time.Now()
+		var (
+			blk *types.Block
+			err error
+		)
+		if job.winner.BlockHash == (common.Hash{}) {
+			blk, err = u.l1Client.BlockByNumber(ctx, big.NewInt(job.winner.BlockNumber))
+			if err != nil {
+				return fmt.Errorf("failed to get block by number: %w", err)
+			}
+		} else {
+			blk, err = u.l1Client.BlockByHash(ctx, job.winner.BlockHash)
+			if err != nil {
+				return fmt.Errorf("failed to get block by hash: %w", err)
+			}
+		}
+		u.observe(stageL1Block, start)
 
-					u.logger.Info(
-						"added settlements",
-						"total", total,
-						"rewards", rewards,
-						"slashes", slashes,
-						"blockNumber", winner.BlockNumber,
-						"winner", winner.Winner,
-					)
+		job.txnsInBlock = make(map[string]int)
+		for posInBlock, tx := range blk.Transactions() {
+			job.txnsInBlock[strings.TrimPrefix(tx.Hash().Hex(), "0x")] = posInBlock
+		}
 
-					return nil
-				}()
+		start = time.Now()
+		commitmentIndexes, err := u.preconfClient.GetCommitmentsByBlockNumber(
+			big.NewInt(job.winner.BlockNumber),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to get commitments by block number: %w", err)
+		}
+		u.observe(stageCommitmentIndexes, start)
+
+		u.logger.Debug(
+			"commitment indexes",
+			"commitments_count", len(commitmentIndexes),
+			"txns_count", len(job.txnsInBlock),
+			"blockNumber", job.winner.BlockNumber,
+		)
+
+		start = time.Now()
+		commitments, err := u.preconfClient.GetCommitments(commitmentIndexes)
+		if err != nil {
+			return fmt.Errorf("failed to get commitments: %w", err)
+		}
+		u.observe(stageCommitments, start)
+
+		job.commitments = make([]fetchedCommitment, len(commitmentIndexes))
+		eg, egCtx := errgroup.WithContext(ctx)
+		for i, commitment := range commitments {
+			select {
+			case <-egCtx.Done():
+				return eg.Wait()
+			case u.workers <- struct{}{}:
+			}
+
+			i, commitment := i, commitment
+			eg.Go(func() error {
+				defer func() { <-u.workers }()
 
+				start := time.Now()
+				l2Block, err := u.l2Client.BlockByNumber(egCtx, commitment.BlockCommitedAt)
 				if err != nil {
-					u.logger.Error("failed to process settlements", "blockNumber", winner.BlockNumber, "winner", winner.Winner, "error", err)
-					unsub()
-					goto RESTART
+					return fmt.Errorf("failed to get L2 Block: %w", err)
 				}
-			}
+				u.observe(stageL2Block, start)
+
+				var receiptStatus ReceiptStatus
+				if u.receiptPolicy != ReceiptPolicyIgnore {
+					start = time.Now()
+					receiptStatus, err = u.receiptStatus(egCtx, commitment.TxnHash, job.txnsInBlock)
+					if err != nil {
+						return err
+					}
+					u.observe(stageReceipts, start)
+				}
+
+				job.commitments[i] = fetchedCommitment{
+					index:      commitmentIndexes[i],
+					commitment: commitment,
+					decay: u.decay.Compute(
+						commitment.DecayStartTimeStamp,
+						commitment.DecayEndTimeStamp,
+						l2Block.Header().Time,
+					),
+					receiptStatus: receiptStatus,
+				}
+				return nil
+			})
 		}
+		return eg.Wait()
 	}()
-
-	return doneChan
 }
 
-// computeDecayPercentage takes startTimestamp, endTimestamp, commitTimestamp and computes a linear decay percentage
-// The computation does not care what format the timestamps are in, as long as they are consistent
-// (e.g they could be unix or unixMili timestamps)
-func computeDecayPercentage(startTimestamp, endTimestamp, commitTimestamp uint64) int64 {
-	if startTimestamp >= endTimestamp || startTimestamp > commitTimestamp {
-		return 0
+// commit writes the settlements of a fetched block and marks it processed.
+func (u *Updater) commit(ctx context.Context, job *blockJob) error {
+	if job.err != nil {
+		return job.err
 	}
 
-	// Calculate the total time in seconds
-	totalTime := endTimestamp - startTimestamp
-	// Calculate the time passed in seconds
-	timePassed := commitTimestamp - startTimestamp
-	// Calculate the decay percentage
-	decayPercentage := float64(timePassed) / float64(totalTime)
+	start := time.Now()
+	winner := job.winner
+
+	var (
+		err         error
+		builderAddr common.Address
+	)
+	// Without a winner or a registered builder none of the commitments
+	// match the zero builder address, so all of them are returned.
+	if !winner.NoWinner && !winner.BuilderUnregistered {
+		var (
+			previous common.Address
+			fetched  bool
+		)
+		builderAddr, previous, fetched, err = u.builderCache.get(winner.Winner)
+		switch {
+		case errors.Is(err, ethereum.NotFound) && u.builderTimeout > 0:
+			return u.park(ctx, winner)
+		case errors.Is(err, ethereum.NotFound):
+			u.logger.Warn("builder not registered", "builder", winner.Winner, "blockNumber", winner.BlockNumber)
+			winner.BuilderUnregistered = true
+		case err != nil:
+			return fmt.Errorf("failed to get builder address: %w", err)
+		case fetched:
+			if previous != (common.Address{}) && previous != builderAddr {
+				u.logger.Info(
+					"builder address changed",
+					"builder", winner.Winner,
+					"previous", previous.Hex(),
+					"address", builderAddr.Hex(),
+					"blockNumber", winner.BlockNumber,
+				)
+				u.metrics.BuilderAddressChangeCount.Inc()
+			}
+			err = u.winnerRegister.RecordBuilderMapping(ctx, winner.Winner, builderAddr, winner.BlockNumber)
+			if err != nil {
+				return fmt.Errorf("failed to record builder mapping: %w", err)
+			}
+		}
+	}
+
+	settlements := make([]Settlement, 0, len(job.commitments))
+	rewards, slashes, returns := 0, 0, 0
+	for _, c := range job.commitments {
+		index, commitment := c.index, c.commitment
+		settlementType := settler.SettlementTypeReturn
+		reason := Reason{
+			Code: ReasonNotWinner,
+			Detail: fmt.Sprintf(
+				"committer %s is not the winner %s (%s)",
+				commitment.Commiter.Hex(),
+				builderAddr.Hex(),
+				winner.Winner,
+			),
+		}
+
+		switch {
+		case winner.NoWinner:
+			reason = Reason{Code: ReasonNoWinner, Detail: "no winner identified for the block"}
+		case winner.BuilderUnregistered:
+			reason = Reason{
+				Code:   ReasonBuilderUnregistered,
+				Detail: fmt.Sprintf("winner %s not registered in the oracle contract", winner.Winner),
+			}
+		case commitment.Commiter.Cmp(builderAddr) == 0:
+			settlementType = settler.SettlementTypeReward
+			reason = Reason{Code: ReasonIncluded}
+
+			// Ensure the bundle is included in the block as required
+			commitmentTxnHashes := strings.Split(commitment.TxnHash, ",")
+			if r := u.bundleValidator.Validate(commitmentTxnHashes, job.txnsInBlock); r != nil {
+				settlementType = settler.SettlementTypeSlash
+				reason = *r
+			}
+
+			if settlementType == settler.SettlementTypeReward && c.receiptStatus == ReceiptStatusReverted {
+				u.metrics.RevertedCommitmentsCount.Inc()
+				reason.Detail = "reverted transactions rewarded by the receipt policy"
+				switch u.receiptPolicy {
+				case ReceiptPolicySlash:
+					settlementType = settler.SettlementTypeSlash
+					reason = Reason{
+						Code:   ReasonTxReverted,
+						Detail: "reverted transactions slashed by the receipt policy",
+					}
+				case ReceiptPolicyFlag:
+					reason.Detail = "reverted transactions flagged for review"
+					u.logger.Warn(
+						"rewarding commitment with reverted transactions",
+						"commitmentIdx", common.Bytes2Hex(index[:]),
+						"txnHash", commitment.TxnHash,
+						"blockNumber", winner.BlockNumber,
+					)
+				}
+			}
+		}
+
+		settlements = append(settlements, Settlement{
+			CommitmentIdx:   index[:],
+			TxHash:          commitment.TxnHash,
+			BlockNum:        winner.BlockNumber,
+			Amount:          commitment.Bid,
+			Builder:         commitment.Commiter.Hex(),
+			BidID:           commitment.CommitmentHash[:],
+			Type:            settlementType,
+			DecayPercentage: c.decay.Scaled,
+			Decay:           c.decay,
+			ReceiptStatus:   c.receiptStatus,
+			Reason:          reason,
+		})
+
+		switch settlementType {
+		case settler.SettlementTypeSlash:
+			slashes++
+		case settler.SettlementTypeReward:
+			rewards++
+		case settler.SettlementTypeReturn:
+			returns++
+		}
+	}
+
+	err = u.winnerRegister.AddSettlements(ctx, winner.BlockNumber, winner.BlockHash, settlements)
+	if err != nil {
+		return fmt.Errorf("failed to add settlements: %w", err)
+	}
+	u.observe(stageCommit, start)
+
+	u.metrics.CommitmentsCount.Add(float64(len(settlements)))
+	u.metrics.RewardsCount.Add(float64(rewards))
+	u.metrics.SlashesCount.Add(float64(slashes))
+	u.metrics.BlockCommitmentsCount.Inc()
+
+	u.logger.Info(
+		"added settlements",
+		"total", len(settlements),
+		"rewards", rewards,
+		"slashes", slashes,
+		"returns", returns,
+		"blockNumber", winner.BlockNumber,
+		"winner", winner.Winner,
+		"noWinner", winner.NoWinner,
+		"builderUnregistered", winner.BuilderUnregistered,
+	)
+
+	return nil
+}
 
-	return int64(math.Round(decayPercentage * 100))
+func (u *Updater) observe(stage string, start time.Time) {
+	u.metrics.StageDuration.WithLabelValues(stage).Observe(time.Since(start).Seconds())
 }
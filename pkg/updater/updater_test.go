@@ -1,6 +1,7 @@
 package updater_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -8,19 +9,35 @@ import (
 	"io"
 	"log/slog"
 	"math/big"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/go-cmp/cmp"
 	preconf "github.com/primevprotocol/contracts-abi/clients/PreConfCommitmentStore"
 	"github.com/primevprotocol/mev-oracle/pkg/settler"
 	"github.com/primevprotocol/mev-oracle/pkg/updater"
 	"golang.org/x/crypto/sha3"
 )
 
+var contiguous, _ = updater.NewBundleValidator(updater.BundlePolicyContiguous)
+
+// msDecay is the decay of the test blocks, whose timestamps are in
+// milliseconds like those of the commitments.
+var msDecay = updater.DecayConfig{
+	Curve:          updater.DecayCurveLinear,
+	CommitmentUnit: updater.Milliseconds,
+	BlockTimeUnit:  updater.Milliseconds,
+	Precision:      100,
+}
+
 func getIdxBytes(idx int64) [32]byte {
 	var idxBytes [32]byte
 	big.NewInt(idx).FillBytes(idxBytes[:])
@@ -151,14 +168,21 @@ func TestUpdater(t *testing.T) {
 		commitments: commitments,
 	}
 
-	updtr := updater.NewUpdater(
-		slog.New(slog.NewTextHandler(io.Discard, nil)),
-		l1Client,
-		l2Client,
-		testWinnerRegister,
-		testOracle,
-		testPreconf,
-	)
+	updtr := updater.NewUpdater(&updater.Options{
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		L1Client:        l1Client,
+		L2Client:        l2Client,
+		WinnerRegister:  testWinnerRegister,
+		RollupClient:    testOracle,
+		PreconfClient:   testPreconf,
+		Parallelism:     4,
+		BuilderCacheTTL: updater.DefaultBuilderCacheTTL,
+		ReceiptPolicy:   updater.ReceiptPolicyIgnore,
+		BundleValidator: contiguous,
+		Decay:           msDecay,
+		RetryPolicy:     updater.DefaultRetryPolicy,
+		BuilderTimeout:  updater.DefaultBuilderTimeout,
+	})
 
 	ctx, cancel := context.WithCancel(context.Background())
 	done := updtr.Start(ctx)
@@ -185,9 +209,15 @@ func TestUpdater(t *testing.T) {
 			t.Fatal("should not be slash")
 		}
 		if settlement.settlementType == settler.SettlementTypeReward {
+			if settlement.reason.Code != updater.ReasonIncluded {
+				t.Fatalf("wrong reward reason %v", settlement.reason)
+			}
 			rewards++
 		}
 		if settlement.settlementType == settler.SettlementTypeReturn {
+			if settlement.reason.Code != updater.ReasonNotWinner {
+				t.Fatalf("wrong return reason %v", settlement.reason)
+			}
 			returns++
 		}
 		count++
@@ -278,14 +308,21 @@ func TestUpdaterBundlesFailure(t *testing.T) {
 		commitments: commitments,
 	}
 
-	updtr := updater.NewUpdater(
-		slog.New(slog.NewTextHandler(io.Discard, nil)),
-		l1Client,
-		l2Client,
-		testWinnerRegister,
-		testOracle,
-		testPreconf,
-	)
+	updtr := updater.NewUpdater(&updater.Options{
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		L1Client:        l1Client,
+		L2Client:        l2Client,
+		WinnerRegister:  testWinnerRegister,
+		RollupClient:    testOracle,
+		PreconfClient:   testPreconf,
+		Parallelism:     4,
+		BuilderCacheTTL: updater.DefaultBuilderCacheTTL,
+		ReceiptPolicy:   updater.ReceiptPolicyIgnore,
+		BundleValidator: contiguous,
+		Decay:           msDecay,
+		RetryPolicy:     updater.DefaultRetryPolicy,
+		BuilderTimeout:  updater.DefaultBuilderTimeout,
+	})
 
 	ctx, cancel := context.WithCancel(context.Background())
 	done := updtr.Start(ctx)
@@ -324,102 +361,1430 @@ func TestUpdaterBundlesFailure(t *testing.T) {
 	}
 }
 
-type testSettlement struct {
-	commitmentIdx   []byte
-	txHash          string
-	blockNum        int64
-	builder         string
-	amount          uint64
-	settlementType  settler.SettlementType
-	decayPercentage int64
-}
+func TestUpdaterNoWinner(t *testing.T) {
+	t.Parallel()
 
-type testWinnerRegister struct {
-	winners     chan updater.BlockWinner
-	settlements chan testSettlement
-	done        chan int64
-}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
 
-func (t *testWinnerRegister) SubscribeWinners(ctx context.Context) <-chan updater.BlockWinner {
-	return t.winners
-}
+	builderAddr := common.HexToAddress("0xabcd")
 
-func (t *testWinnerRegister) UpdateComplete(ctx context.Context, blockNum int64) error {
-	t.done <- blockNum
-	return nil
-}
+	signer := types.NewLondonSigner(big.NewInt(5))
+	var txns []*types.Transaction
+	for i := 0; i < 5; i++ {
+		txns = append(txns, types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			Nonce:     uint64(i + 1),
+			Gas:       1000000,
+			Value:     big.NewInt(1),
+			GasTipCap: big.NewInt(500),
+			GasFeeCap: big.NewInt(500),
+		}))
+	}
 
-func (t *testWinnerRegister) AddSettlement(
-	ctx context.Context,
-	commitmentIdx []byte,
-	txHash string,
-	blockNum int64,
-	amount uint64,
-	builder string,
-	_ []byte,
-	settlementType settler.SettlementType,
-	decayPercentage int64,
-) error {
-	t.settlements <- testSettlement{
-		commitmentIdx:   commitmentIdx,
-		txHash:          txHash,
-		blockNum:        blockNum,
-		amount:          amount,
-		builder:         builder,
-		settlementType:  settlementType,
-		decayPercentage: decayPercentage,
+	commitments := make(map[string]preconf.PreConfCommitmentStorePreConfCommitment)
+	for i, txn := range txns {
+		idxBytes := getIdxBytes(int64(i))
+		commitments[string(idxBytes[:])] = preconf.PreConfCommitmentStorePreConfCommitment{
+			Commiter:        builderAddr,
+			TxnHash:         strings.TrimPrefix(txn.Hash().Hex(), "0x"),
+			CommitmentHash:  common.HexToHash(fmt.Sprintf("0x%02d", i)),
+			BlockCommitedAt: big.NewInt(0),
+		}
 	}
-	return nil
-}
 
-type testL1Client struct {
-	blockNum int64
-	block    *types.Block
-}
+	testWinnerRegister := &testWinnerRegister{
+		winners:     make(chan updater.BlockWinner),
+		settlements: make(chan testSettlement),
+		done:        make(chan int64, 1),
+	}
 
-func (t *testL1Client) BlockByNumber(ctx context.Context, blkNum *big.Int) (*types.Block, error) {
-	if blkNum.Int64() == t.blockNum {
-		return t.block, nil
+	l1Client := &testL1Client{
+		blockNum: 5,
+		block:    types.NewBlock(&types.Header{}, txns, nil, nil, NewHasher()),
 	}
-	return nil, fmt.Errorf("block %d not found", blkNum.Int64())
-}
 
-type testOracle struct {
-	builder     string
-	builderAddr common.Address
-}
+	l2Client := &testL1Client{
+		blockNum: 0,
+		block:    types.NewBlock(&types.Header{Time: uint64(time.Now().UnixMilli())}, txns, nil, nil, NewHasher()),
+	}
 
-func (t *testOracle) GetBuilder(builder string) (common.Address, error) {
-	if builder == t.builder {
-		return t.builderAddr, nil
+	// the oracle does not know the empty builder, so it must not be queried
+	testOracle := &testOracle{
+		builder:     "test",
+		builderAddr: builderAddr,
+	}
+
+	testPreconf := &testPreconf{
+		blockNum:    5,
+		commitments: commitments,
+	}
+
+	updtr := updater.NewUpdater(&updater.Options{
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		L1Client:        l1Client,
+		L2Client:        l2Client,
+		WinnerRegister:  testWinnerRegister,
+		RollupClient:    testOracle,
+		PreconfClient:   testPreconf,
+		Parallelism:     4,
+		BuilderCacheTTL: updater.DefaultBuilderCacheTTL,
+		ReceiptPolicy:   updater.ReceiptPolicyIgnore,
+		BundleValidator: contiguous,
+		Decay:           msDecay,
+		RetryPolicy:     updater.DefaultRetryPolicy,
+		BuilderTimeout:  updater.DefaultBuilderTimeout,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := updtr.Start(ctx)
+
+	testWinnerRegister.winners <- updater.BlockWinner{
+		BlockNumber: 5,
+		NoWinner:    true,
+	}
+
+	for i := 0; i < len(txns); i++ {
+		select {
+		case settlement := <-testWinnerRegister.settlements:
+			if settlement.blockNum != 5 {
+				t.Fatal("wrong block number")
+			}
+			if settlement.settlementType != settler.SettlementTypeReturn {
+				t.Fatalf("should be return, got %s", settlement.settlementType)
+			}
+			if settlement.reason.Code != updater.ReasonNoWinner {
+				t.Fatalf("wrong return reason %v", settlement.reason)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for settlement")
+		}
+	}
+
+	select {
+	case <-testWinnerRegister.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
 	}
-	return common.Address{}, errors.New("builder not found")
 }
 
-type testPreconf struct {
-	blockNum    int64
-	commitments map[string]preconf.PreConfCommitmentStorePreConfCommitment
+func TestUpdaterCommitsInOrder(t *testing.T) {
+	t.Parallel()
+
+	const parallelism = 3
+
+	blocks := []int64{5, 6, 7}
+	l1Client := &testBlocksClient{blocks: make(map[int64]*types.Block)}
+	l2Client := &testSlowL2Client{delays: make(map[int64]time.Duration)}
+	testPreconf := &testBlocksPreconf{commitments: make(map[int64][][32]byte)}
+	for i, blockNum := range blocks {
+		l1Client.blocks[blockNum] = types.NewBlock(&types.Header{}, nil, nil, nil, NewHasher())
+		for j := 0; j < 4; j++ {
+			// The index is also the settlement chain block of the
+			// commitment. The earlier blocks and commitments take longer
+			// to fetch.
+			num := blockNum*10 + int64(j)
+			testPreconf.commitments[blockNum] = append(testPreconf.commitments[blockNum], getIdxBytes(num))
+			l2Client.delays[num] = time.Duration(len(blocks)-i)*20*time.Millisecond +
+				time.Duration(4-j)*5*time.Millisecond
+		}
+	}
+
+	testWinnerRegister := &testWinnerRegister{
+		winners:     make(chan updater.BlockWinner),
+		settlements: make(chan testSettlement),
+		done:        make(chan int64),
+	}
+
+	updtr := updater.NewUpdater(&updater.Options{
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		L1Client:        l1Client,
+		L2Client:        l2Client,
+		WinnerRegister:  testWinnerRegister,
+		RollupClient:    &testOracle{},
+		PreconfClient:   testPreconf,
+		Parallelism:     parallelism,
+		BuilderCacheTTL: updater.DefaultBuilderCacheTTL,
+		ReceiptPolicy:   updater.ReceiptPolicyIgnore,
+		BundleValidator: contiguous,
+		Decay:           msDecay,
+		RetryPolicy:     updater.DefaultRetryPolicy,
+		BuilderTimeout:  updater.DefaultBuilderTimeout,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := updtr.Start(ctx)
+
+	go func() {
+		for _, blockNum := range blocks {
+			testWinnerRegister.winners <- updater.BlockWinner{
+				BlockNumber: blockNum,
+				NoWinner:    true,
+			}
+		}
+	}()
+
+	for _, blockNum := range blocks {
+		for _, idx := range testPreconf.commitments[blockNum] {
+			select {
+			case settlement := <-testWinnerRegister.settlements:
+				if settlement.blockNum != blockNum {
+					t.Fatalf("expected settlement of block %d, got %d", blockNum, settlement.blockNum)
+				}
+				if !bytes.Equal(settlement.commitmentIdx, idx[:]) {
+					t.Fatalf("expected commitment %x, got %x", idx, settlement.commitmentIdx)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timeout waiting for settlement")
+			}
+		}
+
+		select {
+		case completed := <-testWinnerRegister.done:
+			if completed != blockNum {
+				t.Fatalf("expected block %d to complete, got %d", blockNum, completed)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout")
+		}
+	}
+
+	if got := l2Client.maxInFlight.Load(); got > parallelism {
+		t.Fatalf("expected at most %d concurrent fetches, got %d", parallelism, got)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
 }
 
-func (t *testPreconf) GetCommitmentsByBlockNumber(blockNum *big.Int) ([][32]byte, error) {
-	if blockNum.Int64() == t.blockNum {
-		var commitments [][32]byte
-		for idx := range t.commitments {
-			cIdx := [32]byte{}
-			copy(cIdx[:], []byte(idx))
-			commitments = append(commitments, cIdx)
+func TestUpdaterBuilderAddressChange(t *testing.T) {
+	t.Parallel()
+
+	testPreconf := &testBlocksPreconf{
+		commitments: map[int64][][32]byte{
+			5: {getIdxBytes(1)},
+			6: {getIdxBytes(2)},
+		},
+	}
+	l1Client := &testBlocksClient{
+		blocks: map[int64]*types.Block{
+			5: types.NewBlock(&types.Header{}, nil, nil, nil, NewHasher()),
+			6: types.NewBlock(&types.Header{}, nil, nil, nil, NewHasher()),
+		},
+	}
+	l2Client := &testSlowL2Client{}
+
+	testWinnerRegister := &testWinnerRegister{
+		winners:     make(chan updater.BlockWinner),
+		settlements: make(chan testSettlement),
+		done:        make(chan int64),
+	}
+
+	// The commitments are made by 0x1234, which only becomes the address
+	// of the builder after the first block.
+	testOracle := &testOracle{
+		builder:     "test",
+		builderAddr: common.HexToAddress("0xabcd"),
+	}
+
+	updtr := updater.NewUpdater(&updater.Options{
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		L1Client:        l1Client,
+		L2Client:        l2Client,
+		WinnerRegister:  testWinnerRegister,
+		RollupClient:    testOracle,
+		PreconfClient:   testPreconf,
+		Parallelism:     1,
+		BuilderCacheTTL: 0,
+		ReceiptPolicy:   updater.ReceiptPolicyIgnore,
+		BundleValidator: contiguous,
+		Decay:           msDecay,
+		RetryPolicy:     updater.DefaultRetryPolicy,
+		BuilderTimeout:  updater.DefaultBuilderTimeout,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := updtr.Start(ctx)
+
+	for _, tc := range []struct {
+		blockNum       int64
+		settlementType settler.SettlementType
+	}{
+		{blockNum: 5, settlementType: settler.SettlementTypeReturn},
+		{blockNum: 6, settlementType: settler.SettlementTypeSlash},
+	} {
+		testWinnerRegister.winners <- updater.BlockWinner{
+			BlockNumber: tc.blockNum,
+			Winner:      "test",
 		}
-		return commitments, nil
+
+		select {
+		case settlement := <-testWinnerRegister.settlements:
+			if settlement.settlementType != tc.settlementType {
+				t.Fatalf("block %d: expected %s, got %s", tc.blockNum, tc.settlementType, settlement.settlementType)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for settlement")
+		}
+
+		select {
+		case <-testWinnerRegister.done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout")
+		}
+
+		testOracle.builderAddr = common.HexToAddress("0x1234")
 	}
 
-	return nil, errors.New("block not found")
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+
+	want := []testBuilderMapping{
+		{builder: "test", address: common.HexToAddress("0xabcd"), blockNum: 5},
+		{builder: "test", address: common.HexToAddress("0x1234"), blockNum: 6},
+	}
+	testWinnerRegister.mu.Lock()
+	defer testWinnerRegister.mu.Unlock()
+	if diff := cmp.Diff(want, testWinnerRegister.mappings, cmp.AllowUnexported(testBuilderMapping{})); diff != "" {
+		t.Fatalf("unexpected builder mappings (-want +got):\n%s", diff)
+	}
 }
 
-func (t *testPreconf) GetCommitment(
-	commitmentIdx [32]byte,
-) (preconf.PreConfCommitmentStorePreConfCommitment, error) {
-	if commitment, ok := t.commitments[string(commitmentIdx[:])]; ok {
-		return commitment, nil
+func TestUpdaterReceiptPolicy(t *testing.T) {
+	t.Parallel()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
 	}
-	return preconf.PreConfCommitmentStorePreConfCommitment{}, errors.New("commitment not found")
+
+	builderAddr := common.HexToAddress("0xabcd")
+	signer := types.NewLondonSigner(big.NewInt(5))
+	var txns []*types.Transaction
+	for i := 0; i < 2; i++ {
+		txns = append(txns, types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			Nonce:     uint64(i + 1),
+			Gas:       1000000,
+			Value:     big.NewInt(1),
+			GasTipCap: big.NewInt(500),
+			GasFeeCap: big.NewInt(500),
+		}))
+	}
+
+	// The first commitment has a reverted transaction.
+	commitments := make(map[string]preconf.PreConfCommitmentStorePreConfCommitment)
+	for i, txn := range txns {
+		idxBytes := getIdxBytes(int64(i))
+		commitments[string(idxBytes[:])] = preconf.PreConfCommitmentStorePreConfCommitment{
+			Commiter:        builderAddr,
+			TxnHash:         strings.TrimPrefix(txn.Hash().Hex(), "0x"),
+			CommitmentHash:  common.HexToHash(fmt.Sprintf("0x%02d", i)),
+			BlockCommitedAt: big.NewInt(0),
+		}
+	}
+
+	for _, tc := range []struct {
+		policy       updater.ReceiptPolicy
+		revertedType settler.SettlementType
+		wantStatus   bool
+	}{
+		{updater.ReceiptPolicyIgnore, settler.SettlementTypeReward, false},
+		{updater.ReceiptPolicyReward, settler.SettlementTypeReward, true},
+		{updater.ReceiptPolicySlash, settler.SettlementTypeSlash, true},
+		{updater.ReceiptPolicyFlag, settler.SettlementTypeReward, true},
+	} {
+		tc := tc
+		t.Run(string(tc.policy), func(t *testing.T) {
+			t.Parallel()
+
+			testWinnerRegister := &testWinnerRegister{
+				winners:     make(chan updater.BlockWinner),
+				settlements: make(chan testSettlement),
+				done:        make(chan int64, 1),
+			}
+
+			l1Client := &testL1Client{
+				blockNum: 5,
+				block:    types.NewBlock(&types.Header{}, txns, nil, nil, NewHasher()),
+				reverted: map[common.Hash]bool{txns[0].Hash(): true},
+			}
+
+			l2Client := &testL1Client{
+				blockNum: 0,
+				block:    types.NewBlock(&types.Header{}, nil, nil, nil, NewHasher()),
+			}
+
+			updtr := updater.NewUpdater(&updater.Options{
+				Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+				L1Client:        l1Client,
+				L2Client:        l2Client,
+				WinnerRegister:  testWinnerRegister,
+				RollupClient:    &testOracle{builder: "test", builderAddr: builderAddr},
+				PreconfClient:   &testPreconf{blockNum: 5, commitments: commitments},
+				Parallelism:     4,
+				BuilderCacheTTL: updater.DefaultBuilderCacheTTL,
+				ReceiptPolicy:   tc.policy,
+				BundleValidator: contiguous,
+				Decay:           msDecay,
+				RetryPolicy:     updater.DefaultRetryPolicy,
+				BuilderTimeout:  updater.DefaultBuilderTimeout,
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := updtr.Start(ctx)
+
+			testWinnerRegister.winners <- updater.BlockWinner{
+				BlockNumber: 5,
+				Winner:      "test",
+			}
+
+			for i := 0; i < len(txns); i++ {
+				select {
+				case settlement := <-testWinnerRegister.settlements:
+					wantType, wantStatus := settler.SettlementTypeReward, updater.ReceiptStatusSuccess
+					if settlement.txHash == strings.TrimPrefix(txns[0].Hash().Hex(), "0x") {
+						wantType, wantStatus = tc.revertedType, updater.ReceiptStatusReverted
+					}
+					if !tc.wantStatus {
+						wantStatus = ""
+					}
+					if settlement.settlementType != wantType {
+						t.Fatalf("expected %s, got %s", wantType, settlement.settlementType)
+					}
+					if settlement.receiptStatus != wantStatus {
+						t.Fatalf("expected receipt status %q, got %q", wantStatus, settlement.receiptStatus)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatal("timeout waiting for settlement")
+				}
+			}
+
+			select {
+			case <-testWinnerRegister.done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("timeout")
+			}
+
+			cancel()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("timeout")
+			}
+		})
+	}
+}
+
+func TestUpdaterQuarantine(t *testing.T) {
+	t.Parallel()
+
+	// Block 5 cannot be fetched from the L1 client, so it is quarantined
+	// after the maximum number of attempts and block 6 is processed.
+	l1Client := &testBlocksClient{blocks: map[int64]*types.Block{
+		6: types.NewBlock(&types.Header{}, nil, nil, nil, NewHasher()),
+	}}
+	testPreconf := &testBlocksPreconf{commitments: map[int64][][32]byte{6: nil}}
+	register := newTestStoreRegister(
+		updater.BlockWinner{BlockNumber: 5, NoWinner: true},
+		updater.BlockWinner{BlockNumber: 6, NoWinner: true},
+	)
+
+	updtr := updater.NewUpdater(&updater.Options{
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		L1Client:        l1Client,
+		L2Client:        &testSlowL2Client{},
+		WinnerRegister:  register,
+		RollupClient:    &testOracle{},
+		PreconfClient:   testPreconf,
+		Parallelism:     1,
+		BuilderCacheTTL: updater.DefaultBuilderCacheTTL,
+		ReceiptPolicy:   updater.ReceiptPolicyIgnore,
+		BundleValidator: contiguous,
+		Decay:           msDecay,
+		RetryPolicy: updater.RetryPolicy{
+			MaxAttempts: 3,
+			Backoff:     time.Millisecond,
+			MaxBackoff:  2 * time.Millisecond,
+		},
+		BuilderTimeout: updater.DefaultBuilderTimeout,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := updtr.Start(ctx)
+
+	select {
+	case q := <-register.quarantines:
+		if q.blockNum != 5 {
+			t.Fatalf("expected block 5 to be quarantined, got %d", q.blockNum)
+		}
+		if q.attempts != 3 {
+			t.Fatalf("expected 3 attempts, got %d", q.attempts)
+		}
+		if !strings.Contains(q.lastErr, "block 5 not found") {
+			t.Fatalf("unexpected last error %q", q.lastErr)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for quarantine")
+	}
+
+	select {
+	case completed := <-register.done:
+		if completed != 6 {
+			t.Fatalf("expected block 6 to complete, got %d", completed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+}
+
+func TestUpdaterRetryDoesNotDelay(t *testing.T) {
+	t.Parallel()
+
+	// Block 5 cannot be fetched from the L1 client and waits an hour for its
+	// next attempt, while blocks 6 and 7 are processed.
+	l1Client := &testBlocksClient{blocks: map[int64]*types.Block{
+		6: types.NewBlock(&types.Header{}, nil, nil, nil, NewHasher()),
+		7: types.NewBlock(&types.Header{}, nil, nil, nil, NewHasher()),
+	}}
+	testPreconf := &testBlocksPreconf{commitments: map[int64][][32]byte{6: nil, 7: nil}}
+	register := newTestStoreRegister(
+		updater.BlockWinner{BlockNumber: 5, NoWinner: true},
+		updater.BlockWinner{BlockNumber: 6, NoWinner: true},
+		updater.BlockWinner{BlockNumber: 7, NoWinner: true},
+	)
+
+	updtr := updater.NewUpdater(&updater.Options{
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		L1Client:        l1Client,
+		L2Client:        &testSlowL2Client{},
+		WinnerRegister:  register,
+		RollupClient:    &testOracle{},
+		PreconfClient:   testPreconf,
+		Parallelism:     1,
+		BuilderCacheTTL: updater.DefaultBuilderCacheTTL,
+		ReceiptPolicy:   updater.ReceiptPolicyIgnore,
+		BundleValidator: contiguous,
+		Decay:           msDecay,
+		RetryPolicy: updater.RetryPolicy{
+			MaxAttempts: 3,
+			Backoff:     time.Hour,
+			MaxBackoff:  time.Hour,
+		},
+		BuilderTimeout: updater.DefaultBuilderTimeout,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := updtr.Start(ctx)
+
+	for _, want := range []int64{6, 7} {
+		select {
+		case completed := <-register.done:
+			if completed != want {
+				t.Fatalf("expected block %d to complete, got %d", want, completed)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for block %d", want)
+		}
+	}
+
+	// the emitted winner of the block waiting for its retry is skipped
+	register.triggerWinner()
+	select {
+	case q := <-register.quarantines:
+		t.Fatalf("unexpected quarantine of block %d", q.blockNum)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+}
+
+func TestUpdaterUnregisteredBuilder(t *testing.T) {
+	defer updater.SetParkedCheckInterval(10 * time.Millisecond)()
+
+	const timeout = 500 * time.Millisecond
+
+	// The builders of both blocks are not registered, the winner of block
+	// 5 registers while it is parked and the winner of block 6 never does.
+	l1Client := &testBlocksClient{blocks: map[int64]*types.Block{
+		5: types.NewBlock(&types.Header{}, nil, nil, nil, NewHasher()),
+		6: types.NewBlock(&types.Header{}, nil, nil, nil, NewHasher()),
+	}}
+	testPreconf := &testBlocksPreconf{commitments: map[int64][][32]byte{
+		5: {getIdxBytes(51)},
+		6: {getIdxBytes(61)},
+	}}
+	oracle := &testRegistryOracle{builders: make(map[string]common.Address)}
+	register := newTestStoreRegister(
+		updater.BlockWinner{BlockNumber: 5, Winner: "late"},
+		updater.BlockWinner{BlockNumber: 6, Winner: "never"},
+	)
+
+	updtr := updater.NewUpdater(&updater.Options{
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		L1Client:        l1Client,
+		L2Client:        &testSlowL2Client{},
+		WinnerRegister:  register,
+		RollupClient:    oracle,
+		PreconfClient:   testPreconf,
+		Parallelism:     1,
+		BuilderCacheTTL: updater.DefaultBuilderCacheTTL,
+		ReceiptPolicy:   updater.ReceiptPolicyIgnore,
+		BundleValidator: contiguous,
+		Decay:           msDecay,
+		RetryPolicy:     updater.DefaultRetryPolicy,
+		BuilderTimeout:  timeout,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := updtr.Start(ctx)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-register.parks:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for parked blocks")
+		}
+	}
+
+	start := time.Now()
+	oracle.register("late", common.HexToAddress("0x1234"))
+
+	select {
+	case completed := <-register.done:
+		if completed != 5 {
+			t.Fatalf("expected block 5 to complete once its builder registered, got %d", completed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+
+	select {
+	case completed := <-register.done:
+		if completed != 6 {
+			t.Fatalf("expected block 6 to complete, got %d", completed)
+		}
+		if elapsed := time.Since(start); elapsed < timeout/2 {
+			t.Fatalf("expected block 6 to complete after the timeout, got %s", elapsed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+
+	register.mu.Lock()
+	settlements := register.settlements
+	register.mu.Unlock()
+
+	if len(settlements[5]) != 1 {
+		t.Fatalf("expected 1 settlement for block 5, got %d", len(settlements[5]))
+	}
+	switch code := settlements[5][0].Reason.Code; code {
+	case updater.ReasonNotWinner, updater.ReasonBuilderUnregistered:
+		t.Fatalf("expected the commitment of block 5 to match the registered builder, got %s", code)
+	}
+
+	if len(settlements[6]) != 1 {
+		t.Fatalf("expected 1 settlement for block 6, got %d", len(settlements[6]))
+	}
+	if settlements[6][0].Type != settler.SettlementTypeReturn {
+		t.Fatalf("expected return, got %s", settlements[6][0].Type)
+	}
+	if code := settlements[6][0].Reason.Code; code != updater.ReasonBuilderUnregistered {
+		t.Fatalf("expected builder unregistered reason, got %s", code)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+}
+
+func TestUpdaterUnregisteredBuilderDefault(t *testing.T) {
+	defer updater.SetParkedCheckInterval(10 * time.Millisecond)()
+
+	// With the default timeout the block of an unregistered builder is
+	// parked rather than settled until the builder registers.
+	l1Client := &testBlocksClient{blocks: map[int64]*types.Block{
+		5: types.NewBlock(&types.Header{}, nil, nil, nil, NewHasher()),
+	}}
+	testPreconf := &testBlocksPreconf{commitments: map[int64][][32]byte{5: {getIdxBytes(51)}}}
+	oracle := &testRegistryOracle{builders: make(map[string]common.Address)}
+	register := newTestStoreRegister(updater.BlockWinner{BlockNumber: 5, Winner: "late"})
+
+	updtr := updater.NewUpdater(&updater.Options{
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		L1Client:        l1Client,
+		L2Client:        &testSlowL2Client{},
+		WinnerRegister:  register,
+		RollupClient:    oracle,
+		PreconfClient:   testPreconf,
+		Parallelism:     1,
+		BuilderCacheTTL: updater.DefaultBuilderCacheTTL,
+		ReceiptPolicy:   updater.ReceiptPolicyIgnore,
+		BundleValidator: contiguous,
+		Decay:           msDecay,
+		RetryPolicy:     updater.DefaultRetryPolicy,
+		BuilderTimeout:  updater.DefaultBuilderTimeout,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := updtr.Start(ctx)
+
+	select {
+	case <-register.parks:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the block to be parked")
+	}
+	select {
+	case completed := <-register.done:
+		t.Fatalf("unexpected commit of block %d before its builder registered", completed)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	oracle.register("late", common.HexToAddress("0x1234"))
+
+	select {
+	case completed := <-register.done:
+		if completed != 5 {
+			t.Fatalf("expected block 5 to complete once its builder registered, got %d", completed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+
+	register.mu.Lock()
+	settlements := register.settlements[5]
+	register.mu.Unlock()
+
+	if len(settlements) != 1 || settlements[0].Type == settler.SettlementTypeReturn {
+		t.Fatalf("expected the commitment of block 5 to be settled with its builder, got %v", settlements)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+}
+
+func TestUpdaterStaleSnapshot(t *testing.T) {
+	t.Parallel()
+
+	// Blocks 5 and 6 are emitted again by a snapshot taken before their
+	// commit, ahead of block 7 which is not committed yet.
+	l1Client := &testBlocksClient{blocks: map[int64]*types.Block{
+		5: types.NewBlock(&types.Header{}, nil, nil, nil, NewHasher()),
+		6: types.NewBlock(&types.Header{}, nil, nil, nil, NewHasher()),
+		7: types.NewBlock(&types.Header{}, nil, nil, nil, NewHasher()),
+	}}
+	testPreconf := &testBlocksPreconf{commitments: map[int64][][32]byte{5: nil, 6: nil, 7: nil}}
+	register := &testStaleRegister{
+		testStoreRegister: newTestStoreRegister(
+			updater.BlockWinner{BlockNumber: 5, NoWinner: true},
+			updater.BlockWinner{BlockNumber: 6, NoWinner: true},
+			updater.BlockWinner{BlockNumber: 7, NoWinner: true},
+		),
+		committed: make(chan int64, 3),
+	}
+
+	updtr := updater.NewUpdater(&updater.Options{
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		L1Client:        l1Client,
+		L2Client:        &testSlowL2Client{},
+		WinnerRegister:  register,
+		RollupClient:    &testOracle{},
+		PreconfClient:   testPreconf,
+		Parallelism:     1,
+		BuilderCacheTTL: updater.DefaultBuilderCacheTTL,
+		ReceiptPolicy:   updater.ReceiptPolicyIgnore,
+		BundleValidator: contiguous,
+		Decay:           msDecay,
+		RetryPolicy:     updater.DefaultRetryPolicy,
+		BuilderTimeout:  updater.DefaultBuilderTimeout,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := updtr.Start(ctx)
+
+	for _, want := range []int64{5, 6, 7} {
+		select {
+		case completed := <-register.done:
+			if completed != want {
+				t.Fatalf("expected block %d to complete, got %d", want, completed)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout")
+		}
+	}
+
+	select {
+	case completed := <-register.done:
+		t.Fatalf("unexpected second commit of block %d", completed)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+}
+
+func TestUpdaterReorgedWinner(t *testing.T) {
+	t.Parallel()
+
+	// Block 5 is reorged after its winner was read, so its settlements are
+	// only stored for the winner of the new block.
+	oldBlock := types.NewBlock(&types.Header{Number: big.NewInt(5), Extra: []byte("old")}, nil, nil, nil, NewHasher())
+	newBlock := types.NewBlock(&types.Header{Number: big.NewInt(5), Extra: []byte("new")}, nil, nil, nil, NewHasher())
+	l1Client := &testBlocksClient{
+		blocks:  map[int64]*types.Block{5: newBlock},
+		reorged: []*types.Block{oldBlock},
+	}
+	testPreconf := &testBlocksPreconf{commitments: map[int64][][32]byte{5: nil}}
+	register := &testReorgRegister{
+		testStoreRegister: newTestStoreRegister(
+			updater.BlockWinner{BlockNumber: 5, BlockHash: oldBlock.Hash(), NoWinner: true},
+		),
+		reorged: newBlock.Hash(),
+	}
+
+	updtr := updater.NewUpdater(&updater.Options{
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		L1Client:        l1Client,
+		L2Client:        &testSlowL2Client{},
+		WinnerRegister:  register,
+		RollupClient:    &testOracle{},
+		PreconfClient:   testPreconf,
+		Parallelism:     1,
+		BuilderCacheTTL: updater.DefaultBuilderCacheTTL,
+		ReceiptPolicy:   updater.ReceiptPolicyIgnore,
+		BundleValidator: contiguous,
+		Decay:           msDecay,
+		RetryPolicy:     updater.DefaultRetryPolicy,
+		BuilderTimeout:  updater.DefaultBuilderTimeout,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := updtr.Start(ctx)
+
+	select {
+	case completed := <-register.done:
+		if completed != 5 {
+			t.Fatalf("expected block 5 to complete, got %d", completed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+	if attempts := register.attempts.Load(); attempts != 2 {
+		t.Fatalf("expected 2 attempts to add the settlements, got %d", attempts)
+	}
+	if len(register.quarantines) != 0 {
+		t.Fatal("expected the reorged block not to be quarantined")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+}
+
+type testSettlement struct {
+	commitmentIdx   []byte
+	txHash          string
+	blockNum        int64
+	builder         string
+	amount          uint64
+	settlementType  settler.SettlementType
+	decayPercentage int64
+	receiptStatus   updater.ReceiptStatus
+	reason          updater.Reason
+}
+
+type testWinnerRegister struct {
+	winners     chan updater.BlockWinner
+	settlements chan testSettlement
+	done        chan int64
+
+	mu       sync.Mutex
+	mappings []testBuilderMapping
+}
+
+type testBuilderMapping struct {
+	builder  string
+	address  common.Address
+	blockNum int64
+}
+
+func (t *testWinnerRegister) RecordBuilderMapping(
+	ctx context.Context,
+	builder string,
+	address common.Address,
+	blockNum int64,
+) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if n := len(t.mappings); n > 0 && t.mappings[n-1].address == address {
+		return nil
+	}
+	t.mappings = append(t.mappings, testBuilderMapping{builder, address, blockNum})
+	return nil
+}
+
+func (t *testWinnerRegister) QuarantineBlock(
+	ctx context.Context,
+	blockNum int64,
+	attempts int,
+	lastErr string,
+) error {
+	return nil
+}
+
+func (t *testWinnerRegister) ParkBlock(ctx context.Context, blockNum int64, builder string) error {
+	return nil
+}
+
+func (t *testWinnerRegister) ParkedBuilders(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func (t *testWinnerRegister) UnparkBlocks(ctx context.Context, builder string) (int, error) {
+	return 0, nil
+}
+
+func (t *testWinnerRegister) ExpireParkedBlocks(ctx context.Context, timeout time.Duration) (int, error) {
+	return 0, nil
+}
+
+func (t *testWinnerRegister) SubscribeWinners(ctx context.Context) <-chan updater.BlockWinner {
+	return t.winners
+}
+
+func (t *testWinnerRegister) AddSettlements(
+	ctx context.Context,
+	blockNum int64,
+	blockHash common.Hash,
+	settlements []updater.Settlement,
+) error {
+	for _, settlement := range settlements {
+		t.settlements <- testSettlement{
+			commitmentIdx:   settlement.CommitmentIdx,
+			txHash:          settlement.TxHash,
+			blockNum:        settlement.BlockNum,
+			amount:          settlement.Amount,
+			builder:         settlement.Builder,
+			settlementType:  settlement.Type,
+			decayPercentage: settlement.DecayPercentage,
+			receiptStatus:   settlement.ReceiptStatus,
+			reason:          settlement.Reason,
+		}
+	}
+	t.done <- blockNum
+	return nil
+}
+
+type testQuarantine struct {
+	blockNum int64
+	attempts int
+	lastErr  string
+}
+
+// testStoreRegister emits the unprocessed winners on every subscription and
+// again when parked blocks are released, as the store does.
+type testStoreRegister struct {
+	mu          sync.Mutex
+	winners     []updater.BlockWinner
+	processed   map[int64]bool
+	quarantined map[int64]bool
+	parked      map[int64]*testParkedBlock
+	settlements map[int64][]updater.Settlement
+	trigger     chan struct{}
+
+	quarantines chan testQuarantine
+	parks       chan int64
+	done        chan int64
+}
+
+type testParkedBlock struct {
+	builder  string
+	parkedAt time.Time
+	expired  bool
+}
+
+func newTestStoreRegister(winners ...updater.BlockWinner) *testStoreRegister {
+	return &testStoreRegister{
+		winners:     winners,
+		processed:   make(map[int64]bool),
+		quarantined: make(map[int64]bool),
+		parked:      make(map[int64]*testParkedBlock),
+		settlements: make(map[int64][]updater.Settlement),
+		trigger:     make(chan struct{}, 1),
+		quarantines: make(chan testQuarantine, len(winners)),
+		parks:       make(chan int64, len(winners)),
+		done:        make(chan int64, len(winners)),
+	}
+}
+
+func (t *testStoreRegister) RecordBuilderMapping(
+	ctx context.Context,
+	builder string,
+	address common.Address,
+	blockNum int64,
+) error {
+	return nil
+}
+
+func (t *testStoreRegister) pending() []updater.BlockWinner {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	readAt := time.Now()
+	var winners []updater.BlockWinner
+	for _, winner := range t.winners {
+		winner.ReadAt = readAt
+		if t.processed[winner.BlockNumber] || t.quarantined[winner.BlockNumber] {
+			continue
+		}
+		if p, ok := t.parked[winner.BlockNumber]; ok {
+			if !p.expired {
+				continue
+			}
+			winner.BuilderUnregistered = true
+		}
+		winners = append(winners, winner)
+	}
+	return winners
+}
+
+func (t *testStoreRegister) triggerWinner() {
+	select {
+	case t.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (t *testStoreRegister) SubscribeWinners(ctx context.Context) <-chan updater.BlockWinner {
+	winnerChan := make(chan updater.BlockWinner)
+	go func() {
+		defer close(winnerChan)
+		for {
+			for _, winner := range t.pending() {
+				select {
+				case <-ctx.Done():
+					return
+				case winnerChan <- winner:
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.trigger:
+			}
+		}
+	}()
+	return winnerChan
+}
+
+func (t *testStoreRegister) AddSettlements(
+	ctx context.Context,
+	blockNum int64,
+	blockHash common.Hash,
+	settlements []updater.Settlement,
+) error {
+	t.mu.Lock()
+	for _, winner := range t.winners {
+		if winner.BlockNumber == blockNum && winner.BlockHash != blockHash {
+			t.mu.Unlock()
+			return updater.ErrStaleWinner
+		}
+	}
+	t.processed[blockNum] = true
+	t.settlements[blockNum] = settlements
+	t.mu.Unlock()
+
+	t.done <- blockNum
+	return nil
+}
+
+func (t *testStoreRegister) QuarantineBlock(
+	ctx context.Context,
+	blockNum int64,
+	attempts int,
+	lastErr string,
+) error {
+	t.mu.Lock()
+	t.quarantined[blockNum] = true
+	t.mu.Unlock()
+
+	t.quarantines <- testQuarantine{blockNum, attempts, lastErr}
+	return nil
+}
+
+func (t *testStoreRegister) ParkBlock(ctx context.Context, blockNum int64, builder string) error {
+	t.mu.Lock()
+	if _, ok := t.parked[blockNum]; !ok {
+		t.parked[blockNum] = &testParkedBlock{builder: builder, parkedAt: time.Now()}
+	}
+	t.mu.Unlock()
+
+	t.parks <- blockNum
+	return nil
+}
+
+func (t *testStoreRegister) ParkedBuilders(ctx context.Context) ([]string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var builders []string
+	for _, p := range t.parked {
+		if !p.expired && !slices.Contains(builders, p.builder) {
+			builders = append(builders, p.builder)
+		}
+	}
+	return builders, nil
+}
+
+func (t *testStoreRegister) UnparkBlocks(ctx context.Context, builder string) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	count := 0
+	for blockNum, p := range t.parked {
+		if !p.expired && p.builder == builder {
+			delete(t.parked, blockNum)
+			count++
+		}
+	}
+	if count > 0 {
+		t.triggerWinner()
+	}
+	return count, nil
+}
+
+func (t *testStoreRegister) ExpireParkedBlocks(ctx context.Context, timeout time.Duration) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	count := 0
+	for _, p := range t.parked {
+		if !p.expired && time.Since(p.parkedAt) >= timeout {
+			p.expired = true
+			count++
+		}
+	}
+	if count > 0 {
+		t.triggerWinner()
+	}
+	return count, nil
+}
+
+// testStaleRegister emits all but the last winner of a snapshot one at a
+// time, waiting for their commit, and then the whole snapshot again, as a
+// subscription emitting a snapshot taken before the commits does.
+type testStaleRegister struct {
+	*testStoreRegister
+	committed chan int64
+}
+
+func (t *testStaleRegister) SubscribeWinners(ctx context.Context) <-chan updater.BlockWinner {
+	winnerChan := make(chan updater.BlockWinner)
+	go func() {
+		defer close(winnerChan)
+
+		emit := func(winner updater.BlockWinner) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case winnerChan <- winner:
+				return true
+			}
+		}
+
+		snapshot := t.pending()
+		last := len(snapshot) - 1
+		for _, winner := range snapshot[:last] {
+			if !emit(winner) {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.committed:
+			}
+		}
+		for _, winner := range snapshot {
+			if !emit(winner) {
+				return
+			}
+		}
+		<-ctx.Done()
+	}()
+	return winnerChan
+}
+
+func (t *testStaleRegister) AddSettlements(
+	ctx context.Context,
+	blockNum int64,
+	blockHash common.Hash,
+	settlements []updater.Settlement,
+) error {
+	if err := t.testStoreRegister.AddSettlements(ctx, blockNum, blockHash, settlements); err != nil {
+		return err
+	}
+	t.committed <- blockNum
+	return nil
+}
+
+// testReorgRegister registers the winner of a new block with the reorged
+// hash when the settlements of the block are first added.
+type testReorgRegister struct {
+	*testStoreRegister
+	reorged  common.Hash
+	attempts atomic.Int32
+}
+
+func (t *testReorgRegister) AddSettlements(
+	ctx context.Context,
+	blockNum int64,
+	blockHash common.Hash,
+	settlements []updater.Settlement,
+) error {
+	if t.attempts.Add(1) == 1 {
+		t.mu.Lock()
+		for i := range t.winners {
+			if t.winners[i].BlockNumber == blockNum {
+				t.winners[i].BlockHash = t.reorged
+			}
+		}
+		t.mu.Unlock()
+		t.triggerWinner()
+	}
+	return t.testStoreRegister.AddSettlements(ctx, blockNum, blockHash, settlements)
+}
+
+// testRegistryOracle is an oracle contract in which the builders register
+// during the test.
+type testRegistryOracle struct {
+	mu       sync.Mutex
+	builders map[string]common.Address
+}
+
+func (t *testRegistryOracle) register(builder string, address common.Address) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.builders[builder] = address
+}
+
+func (t *testRegistryOracle) GetBuilder(builder string) (common.Address, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if address, ok := t.builders[builder]; ok {
+		return address, nil
+	}
+	return common.Address{}, ethereum.NotFound
+}
+
+type testL1Client struct {
+	blockNum int64
+	block    *types.Block
+	reverted map[common.Hash]bool
+}
+
+func (t *testL1Client) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	status := types.ReceiptStatusSuccessful
+	if t.reverted[txHash] {
+		status = types.ReceiptStatusFailed
+	}
+	return &types.Receipt{TxHash: txHash, Status: status}, nil
+}
+
+func (t *testL1Client) BlockByNumber(ctx context.Context, blkNum *big.Int) (*types.Block, error) {
+	if blkNum.Int64() == t.blockNum {
+		return t.block, nil
+	}
+	return nil, fmt.Errorf("block %d not found", blkNum.Int64())
+}
+
+func (t *testL1Client) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	if t.block.Hash() == hash {
+		return t.block, nil
+	}
+	return nil, fmt.Errorf("block %s not found", hash)
+}
+
+type testBlocksClient struct {
+	blocks map[int64]*types.Block
+	// reorged are the blocks no longer canonical, only found by hash.
+	reorged []*types.Block
+}
+
+func (t *testBlocksClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return &types.Receipt{TxHash: txHash, Status: types.ReceiptStatusSuccessful}, nil
+}
+
+func (t *testBlocksClient) BlockByNumber(ctx context.Context, blkNum *big.Int) (*types.Block, error) {
+	if blk, ok := t.blocks[blkNum.Int64()]; ok {
+		return blk, nil
+	}
+	return nil, fmt.Errorf("block %d not found", blkNum.Int64())
+}
+
+func (t *testBlocksClient) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	for _, blk := range t.blocks {
+		if blk.Hash() == hash {
+			return blk, nil
+		}
+	}
+	for _, blk := range t.reorged {
+		if blk.Hash() == hash {
+			return blk, nil
+		}
+	}
+	return nil, fmt.Errorf("block %s not found", hash)
+}
+
+type testOracle struct {
+	builder     string
+	builderAddr common.Address
+}
+
+func (t *testOracle) GetBuilder(builder string) (common.Address, error) {
+	if builder == t.builder {
+		return t.builderAddr, nil
+	}
+	return common.Address{}, errors.New("builder not found")
+}
+
+type testPreconf struct {
+	blockNum    int64
+	commitments map[string]preconf.PreConfCommitmentStorePreConfCommitment
+}
+
+func (t *testPreconf) GetCommitmentsByBlockNumber(blockNum *big.Int) ([][32]byte, error) {
+	if blockNum.Int64() == t.blockNum {
+		var commitments [][32]byte
+		for idx := range t.commitments {
+			cIdx := [32]byte{}
+			copy(cIdx[:], []byte(idx))
+			commitments = append(commitments, cIdx)
+		}
+		return commitments, nil
+	}
+
+	return nil, errors.New("block not found")
+}
+
+func (t *testPreconf) GetCommitment(
+	commitmentIdx [32]byte,
+) (preconf.PreConfCommitmentStorePreConfCommitment, error) {
+	if commitment, ok := t.commitments[string(commitmentIdx[:])]; ok {
+		return commitment, nil
+	}
+	return preconf.PreConfCommitmentStorePreConfCommitment{}, errors.New("commitment not found")
+}
+
+func (t *testPreconf) GetCommitments(
+	commitmentIdxs [][32]byte,
+) ([]preconf.PreConfCommitmentStorePreConfCommitment, error) {
+	var commitments []preconf.PreConfCommitmentStorePreConfCommitment
+	for _, idx := range commitmentIdxs {
+		commitment, err := t.GetCommitment(idx)
+		if err != nil {
+			return nil, err
+		}
+		commitments = append(commitments, commitment)
+	}
+	return commitments, nil
+}
+
+type testBlocksPreconf struct {
+	commitments map[int64][][32]byte
+}
+
+func (t *testBlocksPreconf) GetCommitmentsByBlockNumber(blockNum *big.Int) ([][32]byte, error) {
+	if commitments, ok := t.commitments[blockNum.Int64()]; ok {
+		return commitments, nil
+	}
+	return nil, errors.New("block not found")
+}
+
+func (t *testBlocksPreconf) GetCommitment(
+	commitmentIdx [32]byte,
+) (preconf.PreConfCommitmentStorePreConfCommitment, error) {
+	return preconf.PreConfCommitmentStorePreConfCommitment{
+		Commiter:        common.HexToAddress("0x1234"),
+		TxnHash:         common.Bytes2Hex(commitmentIdx[:]),
+		BlockCommitedAt: new(big.Int).SetBytes(commitmentIdx[:]),
+	}, nil
+}
+
+func (t *testBlocksPreconf) GetCommitments(
+	commitmentIdxs [][32]byte,
+) ([]preconf.PreConfCommitmentStorePreConfCommitment, error) {
+	var commitments []preconf.PreConfCommitmentStorePreConfCommitment
+	for _, idx := range commitmentIdxs {
+		commitment, err := t.GetCommitment(idx)
+		if err != nil {
+			return nil, err
+		}
+		commitments = append(commitments, commitment)
+	}
+	return commitments, nil
+}
+
+type testSlowL2Client struct {
+	delays      map[int64]time.Duration
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func (t *testSlowL2Client) BlockByNumber(ctx context.Context, blkNum *big.Int) (*types.Block, error) {
+	n := t.inFlight.Add(1)
+	defer t.inFlight.Add(-1)
+	for {
+		m := t.maxInFlight.Load()
+		if n <= m || t.maxInFlight.CompareAndSwap(m, n) {
+			break
+		}
+	}
+
+	time.Sleep(t.delays[blkNum.Int64()])
+	return types.NewBlock(&types.Header{}, nil, nil, nil, NewHasher()), nil
 }